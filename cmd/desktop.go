@@ -3,10 +3,15 @@
 package main
 
 import (
+	"app/internal/cheat"
 	"app/internal/logger"
+	"app/internal/model"
+	"app/internal/movie"
 	"app/internal/ui"
 	"flag"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 func platformInit() {
@@ -14,9 +19,31 @@ func platformInit() {
 }
 
 func platformMain() {
+	// Subcommands come before the emulator flags
+	if len(os.Args) > 1 && os.Args[1] == "rominfo" {
+		os.Exit(runRomInfo(os.Args[2:]))
+	}
+
 	// Parse command line flags
 	var debugMode = flag.Bool("debug", false, "Enable debug mode")
 	var showFPS = flag.Bool("fps", false, "Show FPS counter")
+	var speed = flag.Float64("speed", 1.0, "Emulation speed multiplier (0.25-1 slow motion, >1 fast-forward)")
+	var ffSpeed = flag.Float64("ff-speed", ui.DefaultFastForwardSpeed, "Speed multiplier used by the fast-forward hotkeys")
+	var uncapped = flag.Bool("uncapped", false, "Run without frame limiting (benchmarks)")
+	var inputConfig = flag.String("input-config", "", "JSON file with key and gamepad bindings")
+	var bind = flag.String("bind", "", "Binding overrides, e.g. a=K,b=J,pad.a=RightBottom")
+	var allowOpposing = flag.Bool("allow-opposing", false, "Allow Left+Right and Up+Down to be pressed together")
+	var recordMovie = flag.String("record", "", "Record joypad input to a movie file")
+	var playMovie = flag.String("play", "", "Play back a movie file")
+	var movieRW = flag.Bool("movie-rw", false, "Play the movie read-write: recording continues at its end")
+	var headless = flag.Bool("headless", false, "Play the movie without a window and print the final frame hash")
+	var frames = flag.Int("frames", 0, "Frames to run in headless mode (default: movie length)")
+	var cheatFile = flag.String("cheats", "", "Cheat file (default: <rom>.cht next to the ROM)")
+	var strict = flag.Bool("strict", false, "Refuse ROMs with header problems (bad checksums, logo, size)")
+	var romEntry = flag.String("rom-entry", "", "File to load from a zip archive (default: first .gb/.gbc)")
+	var patchFile = flag.String("patch", "", "IPS/BPS/UPS patch (default: <rom>.bps/.ups/.ips next to the ROM)")
+	var oamBug = flag.Bool("oam-bug", false, "Emulate the DMG OAM corruption bug")
+	var modelName = flag.String("model", "dmg", "Hardware model: dmg0, dmg, mgb, sgb, sgb2, cgb or agb. CGB carts run in DMG mode; sgb, sgb2, cgb and agb use the DMG's DIV phase as a placeholder")
 	flag.Parse()
 
 	// Apply configuration
@@ -27,14 +54,122 @@ func platformMain() {
 	args := flag.Args()
 	if len(args) < 1 {
 		logger.Error("Usage: %s [options] <rom_file>", os.Args[0])
+		logger.Error("       %s rominfo [-json] <rom or directory>...", os.Args[0])
 		logger.Info("Options:")
 		logger.Info("  -debug        Enable debug mode")
 		logger.Info("  -fps          Show FPS counter")
+		logger.Info("  -speed N      Emulation speed multiplier")
+		logger.Info("  -ff-speed N   Fast-forward multiplier (default %.0f)", ui.DefaultFastForwardSpeed)
+		logger.Info("  -uncapped     Run without frame limiting")
+		logger.Info("  -input-config FILE  JSON key/gamepad bindings")
+		logger.Info("  -bind SPEC    Binding overrides, e.g. a=K,b=J,pad.a=RightBottom")
+		logger.Info("  -allow-opposing  Allow opposing D-pad directions")
+		logger.Info("  -record FILE  Record joypad input to a movie")
+		logger.Info("  -play FILE    Play back a movie (F7 read-only, F8 branch)")
+		logger.Info("  -movie-rw     Play the movie read-write")
+		logger.Info("  -headless     Play the movie without a window")
+		logger.Info("  -frames N     Frames to run in headless mode")
+		logger.Info("  -cheats FILE  Cheat file (default: <rom>.cht)")
+		logger.Info("  -patch FILE   IPS/BPS/UPS patch applied in memory")
+		logger.Info("  -rom-entry NAME  File to load from a zip archive")
+		logger.Info("  -strict       Refuse ROMs with header problems")
+		logger.Info("  -oam-bug      Emulate the DMG OAM corruption bug")
+		logger.Info("  -model NAME   Hardware model: dmg0, dmg, mgb, sgb, sgb2, cgb or agb")
+		logger.Info("                (CGB carts run in DMG mode; sgb, sgb2, cgb and agb")
+		logger.Info("                start DIV at the DMG's phase, a placeholder)")
 		os.Exit(1)
 	}
 
 	romFile := args[0]
 
-	emuInstance := ui.StartEmulator(romFile)
-	ui.UiInit(emuInstance, *showFPS)
+	hwModel, err := model.Parse(*modelName)
+	if err != nil {
+		logger.Fatal("Invalid -model: %v", err)
+	}
+
+	emuInstance, warnings, err := ui.StartEmulator(romFile, ui.RomOptions{PatchFile: *patchFile, ArchiveEntry: *romEntry, OamBug: *oamBug, Model: hwModel})
+	if err != nil {
+		logger.Fatal("ROM loading failed: %v", err)
+	}
+	if len(warnings) > 0 && *strict {
+		logger.Fatal("ROM has %d header problem(s), refusing to run in -strict mode", len(warnings))
+	}
+
+	if *cheatFile == "" {
+		*cheatFile = strings.TrimSuffix(romFile, filepath.Ext(romFile)) + ".cht"
+	}
+	cheats, err := cheat.Load(*cheatFile)
+	if err != nil {
+		logger.Fatal("Failed to load cheats: %v", err)
+	}
+	emuInstance.SetCheats(cheats)
+
+	session := openMovie(emuInstance, *recordMovie, *playMovie, *movieRW)
+	if *headless {
+		if session == nil || *recordMovie != "" {
+			logger.Fatal("-headless requires -play")
+		}
+		run := ui.RunHeadless(emuInstance, session, *frames, nil)
+		logger.Info("Headless: %d frames, frame hash %08X", run, emuInstance.FrameHash())
+		if err := session.Save(); err != nil {
+			logger.Fatal("Failed to save movie: %v", err)
+		}
+		return
+	}
+
+	cfg := ui.DefaultUiConfig()
+	cfg.Movie = session
+	cfg.ShowFPS = *showFPS
+	cfg.Speed = *speed
+	cfg.FastForwardSpeed = *ffSpeed
+	cfg.Uncapped = *uncapped
+	if *inputConfig != "" {
+		inputCfg, err := ui.LoadInputConfig(*inputConfig)
+		if err != nil {
+			logger.Fatal("Failed to load input config: %v", err)
+		}
+		cfg.Input = inputCfg
+	}
+	if *bind != "" {
+		if err := cfg.Input.ApplyBindOverrides(*bind); err != nil {
+			logger.Fatal("Invalid -bind: %v", err)
+		}
+	}
+	if _, err := ui.NewBindings(cfg.Input); err != nil {
+		logger.Fatal("Invalid input bindings: %v", err)
+	}
+	if *allowOpposing {
+		cfg.Input.AllowOpposingDirections = true
+	}
+
+	if err := ui.UiInit(emuInstance, cfg); err != nil {
+		logger.Fatal("Failed to run game: %v", err)
+	}
+}
+
+// openMovie sets up recording or playback from the command line flags
+func openMovie(emu *ui.EmuContext, recordPath string, playPath string, readWrite bool) *movie.Session {
+	title, crc := emu.CartCtx.Title(), emu.CartCtx.RomCRC32()
+
+	switch {
+	case recordPath != "" && playPath != "":
+		logger.Fatal("-record and -play can't be used together")
+	case recordPath != "":
+		logger.Info("Recording movie to %s", recordPath)
+		return movie.NewRecording(recordPath, title, crc, emu.MovieSettings())
+	case playPath != "":
+		m, err := movie.Load(playPath)
+		if err != nil {
+			logger.Fatal("Failed to load movie: %v", err)
+		}
+		if err := m.Verify(title, crc); err != nil {
+			logger.Fatal("Movie doesn't match ROM: %v", err)
+		}
+		if err := m.VerifySettings(emu.MovieSettings()); err != nil {
+			logger.Fatal("Movie doesn't match settings: %v", err)
+		}
+		logger.Info("Playing movie %s (%d frames, %d rerecords)", playPath, len(m.Frames), m.Rerecords)
+		return movie.NewPlayback(m, playPath, !readWrite)
+	}
+	return nil
 }
@@ -0,0 +1,150 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"app/internal/memory"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// romInfoResult is one entry of the rominfo output
+type romInfoResult struct {
+	File string `json:"file"`
+	*memory.RomInfo
+	Error string `json:"error,omitempty"`
+}
+
+// romExtensions are the files rominfo picks up when given a directory
+var romExtensions = map[string]bool{".gb": true, ".gbc": true, ".zip": true, ".gz": true}
+
+// runRomInfo implements "gomulator rominfo [-json] <rom or directory>..."
+func runRomInfo(args []string) int {
+	flags := flag.NewFlagSet("rominfo", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print a JSON array instead of text")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s rominfo [-json] <rom or directory>...\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	var files []string
+	for _, arg := range flags.Args() {
+		found, err := collectRoms(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "rominfo: %v\n", err)
+			return 1
+		}
+		files = append(files, found...)
+	}
+
+	results := make([]romInfoResult, 0, len(files))
+	failed := false
+	for _, file := range files {
+		result := romInfoResult{File: file}
+		info, err := readRomInfo(file)
+		if err != nil {
+			result.Error = err.Error()
+			failed = true
+		}
+		result.RomInfo = info
+		results = append(results, result)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			fmt.Fprintf(os.Stderr, "rominfo: %v\n", err)
+			return 1
+		}
+	} else {
+		for i, r := range results {
+			if i > 0 {
+				fmt.Println()
+			}
+			printRomInfo(r)
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// collectRoms expands a directory into the ROM files below it
+func collectRoms(path string) ([]string, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !st.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && romExtensions[strings.ToLower(filepath.Ext(p))] {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func readRomInfo(file string) (*memory.RomInfo, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	rom, err := memory.ExtractROM(data, "")
+	if err != nil {
+		return nil, err
+	}
+	return memory.ReadRomInfo(rom)
+}
+
+func printRomInfo(r romInfoResult) {
+	fmt.Printf("File:            %s\n", r.File)
+	if r.Error != "" {
+		fmt.Printf("Error:           %s\n", r.Error)
+		return
+	}
+
+	yesNo := map[bool]string{true: "yes", false: "no"}
+	okBad := map[bool]string{true: "OK", false: "BAD"}
+	ramSize := "unknown"
+	if r.RamSize >= 0 {
+		ramSize = fmt.Sprintf("%d KB", r.RamSize/1024)
+	}
+
+	fmt.Printf("Title:           %s\n", r.Title)
+	fmt.Printf("CGB:             %s\n", r.CGB)
+	fmt.Printf("SGB:             %s\n", yesNo[r.SGB])
+	fmt.Printf("Mapper:          %02X (%s)\n", r.CartType, r.Mapper)
+	fmt.Printf("ROM size:        %d KB (file %d bytes)\n", r.RomSize/1024, r.FileSize)
+	fmt.Printf("RAM size:        %s\n", ramSize)
+	fmt.Printf("Licensee:        %s (%s)\n", r.LicenseeCode, r.Licensee)
+	fmt.Printf("Destination:     %s\n", r.Destination)
+	fmt.Printf("Version:         %02X\n", r.Version)
+	fmt.Printf("Header checksum: %s\n", okBad[r.HeaderChecksum])
+	fmt.Printf("Global checksum: %s\n", okBad[r.GlobalChecksum])
+	fmt.Printf("CRC32:           %08X\n", r.CRC32)
+	for _, p := range r.Problems {
+		fmt.Printf("Problem:         %s\n", p)
+	}
+}
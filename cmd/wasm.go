@@ -3,6 +3,7 @@
 package main
 
 import (
+	"app/internal/cheat"
 	"app/internal/input"
 	"app/internal/logger"
 	"app/internal/ui"
@@ -13,6 +14,12 @@ import (
 // can access the bus for ad-hoc reads.
 var currentEmu *ui.EmuContext
 
+// romRequest is a ROM handed over by startEmulatorWithROM
+type romRequest struct {
+	data []byte
+	opts ui.RomOptions
+}
+
 func platformInit() {
 	// WASM-specific initialization
 	logger.Info("Running in WASM/browser mode")
@@ -22,7 +29,7 @@ func platformMain() {
 	logger.Info("Waiting for ROM from JavaScript...")
 	// Channel used to send ROM bytes to the main goroutine so UiInit runs
 	// on the main thread (required by some windowing/JS interactions).
-	romStartCh := make(chan []byte, 1)
+	romStartCh := make(chan romRequest, 1)
 
 	js.Global().Set("startEmulatorWithROM", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		if len(args) < 1 {
@@ -35,10 +42,16 @@ func platformMain() {
 		romBytes := make([]byte, romData.Get("length").Int())
 		js.CopyBytesToGo(romBytes, romData)
 
+		// Optional second argument: file to load from a zip archive
+		var opts ui.RomOptions
+		if len(args) > 1 && args[1].Type() == js.TypeString {
+			opts.ArchiveEntry = args[1].String()
+		}
+
 		logger.Info("ROM received from JS (%d bytes), enqueuing for start...", len(romBytes))
 		// Enqueue the ROM for the main goroutine to pick up and start the UI
 		select {
-		case romStartCh <- romBytes:
+		case romStartCh <- romRequest{data: romBytes, opts: opts}:
 		default:
 			// If channel already has a pending startup, drop or log
 			logger.Warn("startEmulatorWithROM: previous ROM start pending, ignoring new request")
@@ -55,27 +68,12 @@ func platformMain() {
 		btn := args[0].String()
 		pressed := args[1].Bool()
 
-		st := input.GetState()
-		switch btn {
-		case "up":
-			st.Up = pressed
-		case "down":
-			st.Down = pressed
-		case "left":
-			st.Left = pressed
-		case "right":
-			st.Right = pressed
-		case "a":
-			st.A = pressed
-		case "b":
-			st.B = pressed
-		case "start":
-			st.Start = pressed
-		case "select":
-			st.Select = pressed
-		default:
+		b, ok := input.ParseButton(btn)
+		if !ok {
 			js.Global().Get("console").Call("warn", "emuInput: unknown button", btn)
+			return nil
 		}
+		input.GetHostState().Set(b, pressed)
 
 		return nil
 	})
@@ -99,43 +97,72 @@ func platformMain() {
 				pressed = p.Bool()
 			}
 			js.Global().Get("console").Call("log", "emu-input payload:", btn, pressed)
-			st := input.GetState()
-			switch btn {
-			case "up":
-				st.Up = pressed
-			case "down":
-				st.Down = pressed
-			case "left":
-				st.Left = pressed
-			case "right":
-				st.Right = pressed
-			case "a":
-				st.A = pressed
-			case "b":
-				st.B = pressed
-			case "start":
-				st.Start = pressed
-			case "select":
-				st.Select = pressed
-			default:
+			b, ok := input.ParseButton(btn)
+			if !ok {
 				js.Global().Get("console").Call("warn", "message handler: unknown button", btn)
+				return nil
 			}
+			input.GetHostState().Set(b, pressed)
 		}
 		return nil
 	})
 	js.Global().Set("emuMessageHandler", msgHandler)
 	js.Global().Call("addEventListener", "message", msgHandler)
 
+	// Cheats: emuAddCheat(code, name), emuToggleCheat(index) and
+	// emuListCheats() let the host page manage codes for the running ROM
+	js.Global().Set("emuAddCheat", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 1 || currentEmu == nil {
+			return false
+		}
+		name := ""
+		if len(args) > 1 {
+			name = args[1].String()
+		}
+		if _, err := currentEmu.Cheats.Add(args[0].String(), name); err != nil {
+			js.Global().Get("console").Call("warn", "emuAddCheat:", err.Error())
+			return false
+		}
+		return true
+	}))
+	js.Global().Set("emuToggleCheat", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 1 || currentEmu == nil {
+			return false
+		}
+		return currentEmu.Cheats.Toggle(args[0].Int())
+	}))
+	js.Global().Set("emuListCheats", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		list := []interface{}{}
+		if currentEmu != nil {
+			for _, c := range currentEmu.Cheats.Cheats {
+				list = append(list, c.String())
+			}
+		}
+		return list
+	}))
+
 	// Main loop: wait for ROMs to start. This keeps the main goroutine alive
 	// and ensures UiInit (which calls ebiten.RunGame) runs on the main thread.
 	for {
-		rom := <-romStartCh
-		logger.Info("Starting emulator from enqueued ROM (%d bytes)", len(rom))
-		emuInstance := ui.StartEmulatorFromBytes(rom)
+		req := <-romStartCh
+		logger.Info("Starting emulator from enqueued ROM (%d bytes)", len(req.data))
+		emuInstance, warnings, err := ui.StartEmulatorFromBytes(req.data, req.opts)
+		if err != nil {
+			// Keep the Go runtime alive so the page can offer another ROM
+			logger.Error("ROM loading failed: %v", err)
+			js.Global().Get("console").Call("error", "startEmulatorWithROM: "+err.Error())
+			continue
+		}
+		for _, w := range warnings {
+			js.Global().Get("console").Call("warn", "ROM: "+w.Error())
+		}
+		emuInstance.SetCheats(cheat.NewList(""))
 		// Save the current emu instance for debug reads
 		currentEmu = emuInstance
 		// Run the UI (blocks until the emulator stops)
-		ui.UiInit(emuInstance, false)
+		if err := ui.UiInit(emuInstance, ui.DefaultUiConfig()); err != nil {
+			logger.Error("Failed to run game: %v", err)
+		}
 		logger.Info("UiInit returned; emulator stopped or exited")
 		currentEmu = nil
 	}
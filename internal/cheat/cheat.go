@@ -0,0 +1,122 @@
+package cheat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind identifies the cheat device a code was written for
+type Kind int
+
+const (
+	GameGenie Kind = iota // ROM patch applied when the cartridge is read
+	GameShark             // RAM write applied once per frame
+)
+
+func (k Kind) String() string {
+	if k == GameShark {
+		return "GameShark"
+	}
+	return "Game Genie"
+}
+
+// Cheat is a single decoded code
+type Cheat struct {
+	Code    string
+	Name    string
+	Enabled bool
+	Kind    Kind
+
+	Address    uint16
+	Value      byte
+	Compare    byte // Game Genie: ROM byte that must be present for the patch to apply
+	HasCompare bool
+	Bank       byte // GameShark: 0x01 = current bank, 0x80-0x8F = explicit RAM bank
+}
+
+// Parse decodes a Game Genie code ("ABC-DEF-GHI" or "ABC-DEF") or a
+// GameShark code ("ttvvaaaa")
+func Parse(code string) (*Cheat, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	digits := strings.ReplaceAll(code, "-", "")
+
+	if _, err := strconv.ParseUint(digits, 16, 64); err != nil || digits == "" {
+		return nil, fmt.Errorf("invalid cheat code %q", code)
+	}
+
+	switch {
+	case strings.Contains(code, "-") && (len(digits) == 9 || len(digits) == 6):
+		return parseGameGenie(code, digits)
+	case len(digits) == 8:
+		return parseGameShark(code, digits)
+	}
+	return nil, fmt.Errorf("unrecognised cheat code %q (expected ABC-DEF-GHI or 8 hex digits)", code)
+}
+
+// parseGameGenie decodes ABC-DEF-GHI: AB is the new value, FCDE with F
+// inverted is the ROM address, and GI rotated right by 2 and XORed with
+// 0xBA is the compare byte. H is not used by the decoder.
+func parseGameGenie(code string, digits string) (*Cheat, error) {
+	nibble := func(i int) uint16 {
+		v, _ := strconv.ParseUint(digits[i:i+1], 16, 8)
+		return uint16(v)
+	}
+
+	c := &Cheat{
+		Code:    code,
+		Enabled: true,
+		Kind:    GameGenie,
+		Value:   byte(nibble(0)<<4 | nibble(1)),
+		Address: (nibble(5)^0xF)<<12 | nibble(2)<<8 | nibble(3)<<4 | nibble(4),
+	}
+	if c.Address >= 0x8000 {
+		return nil, fmt.Errorf("Game Genie code %q patches %04X, outside cartridge ROM", code, c.Address)
+	}
+
+	if len(digits) == 9 {
+		gi := byte(nibble(6)<<4 | nibble(8))
+		c.Compare = (gi>>2 | gi<<6) ^ 0xBA
+		c.HasCompare = true
+	}
+	return c, nil
+}
+
+// parseGameShark decodes ttvvaaaa: tt is the type/bank, vv the value and
+// aaaa the little-endian address, which must be in cartridge RAM or WRAM
+func parseGameShark(code string, digits string) (*Cheat, error) {
+	raw, _ := strconv.ParseUint(digits, 16, 32)
+
+	c := &Cheat{
+		Code:    code,
+		Enabled: true,
+		Kind:    GameShark,
+		Bank:    byte(raw >> 24),
+		Value:   byte(raw >> 16),
+		Address: uint16(raw&0xFF)<<8 | uint16(raw>>8&0xFF),
+	}
+	if c.Address < 0xA000 || c.Address >= 0xE000 {
+		return nil, fmt.Errorf("GameShark code %q writes %04X, outside RAM (A000-DFFF)", code, c.Address)
+	}
+	return c, nil
+}
+
+// ExplicitBank returns the RAM bank selected by a GameShark code, or false if
+// the code writes to whatever bank is currently mapped
+func (c *Cheat) ExplicitBank() (int, bool) {
+	if c.Bank&0xF0 == 0x80 {
+		return int(c.Bank & 0x0F), true
+	}
+	return 0, false
+}
+
+func (c *Cheat) String() string {
+	state := "off"
+	if c.Enabled {
+		state = "on"
+	}
+	if c.Name == "" {
+		return fmt.Sprintf("[%s] %s", state, c.Code)
+	}
+	return fmt.Sprintf("[%s] %s %s", state, c.Code, c.Name)
+}
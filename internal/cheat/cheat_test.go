@@ -0,0 +1,88 @@
+package cheat
+
+import "testing"
+
+func TestParseGameGenie(t *testing.T) {
+	tests := []struct {
+		code       string
+		address    uint16
+		value      byte
+		compare    byte
+		hasCompare bool
+	}{
+		{"00A-17B-C49", 0x4A17, 0x00, 0xC8, true},
+		{"00a-17b-c49", 0x4A17, 0x00, 0xC8, true},
+		{"3E1-2FA", 0x512F, 0x3E, 0, false},
+		{"FF0-00F", 0x0000, 0xFF, 0, false},
+		{"122-34E-000", 0x1234, 0x12, 0xBA, true},
+	}
+
+	for _, tt := range tests {
+		c, err := Parse(tt.code)
+		if err != nil {
+			t.Errorf("%s: %v", tt.code, err)
+			continue
+		}
+		if c.Kind != GameGenie || c.Address != tt.address || c.Value != tt.value ||
+			c.Compare != tt.compare || c.HasCompare != tt.hasCompare || !c.Enabled {
+			t.Errorf("%s: got %s %04X=%02X compare %02X/%v, want %04X=%02X compare %02X/%v",
+				tt.code, c.Kind, c.Address, c.Value, c.Compare, c.HasCompare,
+				tt.address, tt.value, tt.compare, tt.hasCompare)
+		}
+	}
+}
+
+func TestParseGameShark(t *testing.T) {
+	tests := []struct {
+		code     string
+		address  uint16
+		value    byte
+		bank     int
+		explicit bool
+	}{
+		{"01FF21D0", 0xD021, 0xFF, 0, false},
+		{"010500A0", 0xA000, 0x05, 0, false},
+		{"0163FFDF", 0xDFFF, 0x63, 0, false},
+		{"8005A0A0", 0xA0A0, 0x05, 0, true},
+		{"8A05A0A0", 0xA0A0, 0x05, 10, true},
+		{"8F0100B0", 0xB000, 0x01, 15, true},
+		{"9001A0A0", 0xA0A0, 0x01, 0, false},
+	}
+
+	for _, tt := range tests {
+		c, err := Parse(tt.code)
+		if err != nil {
+			t.Errorf("%s: %v", tt.code, err)
+			continue
+		}
+		bank, explicit := c.ExplicitBank()
+		if c.Kind != GameShark || c.Address != tt.address || c.Value != tt.value ||
+			bank != tt.bank || explicit != tt.explicit {
+			t.Errorf("%s: got %s %04X=%02X bank %d/%v, want %04X=%02X bank %d/%v",
+				tt.code, c.Kind, c.Address, c.Value, bank, explicit,
+				tt.address, tt.value, tt.bank, tt.explicit)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := map[string]string{
+		"empty":             "",
+		"not hex":           "XYZ-123",
+		"too short":         "12345",
+		"too long":          "0123456789",
+		"genie dash count":  "0123-4567",
+		"genie past ROM":    "001-237",
+		"shark in ROM":      "01FF0040",
+		"shark in VRAM":     "01FF0080",
+		"shark in echo RAM": "01FF00E0",
+		"shark in OAM":      "01FF00FE",
+		"shark in IO":       "01FF40FF",
+		"shark on IE":       "01FFFFFF",
+	}
+	for name, code := range tests {
+		if c, err := Parse(code); err == nil {
+			t.Errorf("%s: %q parsed as %s %04X", name, code, c.Kind, c.Address)
+		}
+	}
+}
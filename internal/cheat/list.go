@@ -0,0 +1,180 @@
+package cheat
+
+import (
+	"app/internal/logger"
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+/*
+Cheat file format, one code per line:
+
+	# Super Mario Land
+	+ 00A-17B-C49 Infinite lives
+	- 01FF21D0    Max coins
+
+'+' marks an enabled code and '-' a disabled one. Everything after the code
+is its name.
+*/
+
+// List holds the cheats for the loaded ROM
+type List struct {
+	Cheats []*Cheat
+
+	path  string
+	genie map[uint16][]*Cheat // Enabled Game Genie codes by address
+	shark []*Cheat            // Enabled GameShark codes
+	dirty bool
+}
+
+// NewList creates an empty cheat list that is saved to path
+func NewList(path string) *List {
+	l := &List{path: path}
+	l.rebuild()
+	return l
+}
+
+// Load reads a cheat file. A missing file gives an empty list.
+func Load(path string) (*List, error) {
+	l := NewList(path)
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		enabled := true
+		switch line[0] {
+		case '+':
+			line = strings.TrimSpace(line[1:])
+		case '-':
+			enabled = false
+			line = strings.TrimSpace(line[1:])
+		}
+
+		code, name, _ := strings.Cut(line, " ")
+		c, err := Parse(code)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		c.Name = strings.TrimSpace(name)
+		c.Enabled = enabled
+		l.Cheats = append(l.Cheats, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	l.rebuild()
+	logger.Info("Cheats: loaded %d codes from %s", len(l.Cheats), path)
+	return l, nil
+}
+
+// Save writes the list back to its file if codes were added or toggled
+func (l *List) Save() error {
+	if l.path == "" || !l.dirty {
+		return nil
+	}
+
+	var sb strings.Builder
+	for _, c := range l.Cheats {
+		state := '+'
+		if !c.Enabled {
+			state = '-'
+		}
+		fmt.Fprintf(&sb, "%c %s %s\n", state, c.Code, c.Name)
+	}
+	if err := os.WriteFile(l.path, []byte(sb.String()), 0644); err != nil {
+		return err
+	}
+	l.dirty = false
+	return nil
+}
+
+// Add parses and appends a code
+func (l *List) Add(code string, name string) (*Cheat, error) {
+	c, err := Parse(code)
+	if err != nil {
+		return nil, err
+	}
+	c.Name = name
+	l.Cheats = append(l.Cheats, c)
+	l.dirty = true
+	l.rebuild()
+	return c, nil
+}
+
+// Toggle enables or disables the cheat at index i
+func (l *List) Toggle(i int) bool {
+	if i < 0 || i >= len(l.Cheats) {
+		return false
+	}
+	c := l.Cheats[i]
+	c.Enabled = !c.Enabled
+	l.dirty = true
+	l.rebuild()
+	logger.Info("Cheats: %s", c)
+	return true
+}
+
+// rebuild refreshes the lookup tables after a change
+func (l *List) rebuild() {
+	l.genie = make(map[uint16][]*Cheat)
+	l.shark = l.shark[:0]
+	for _, c := range l.Cheats {
+		if !c.Enabled {
+			continue
+		}
+		if c.Kind == GameGenie {
+			l.genie[c.Address] = append(l.genie[c.Address], c)
+		} else {
+			l.shark = append(l.shark, c)
+		}
+	}
+}
+
+// PatchRead applies Game Genie codes to a ROM read. value is the byte the
+// cartridge returned; codes with a compare byte only apply when it matches,
+// which limits them to the intended ROM bank.
+func (l *List) PatchRead(address uint16, value byte) byte {
+	if len(l.genie) == 0 {
+		return value
+	}
+	for _, c := range l.genie[address] {
+		if !c.HasCompare || c.Compare == value {
+			return c.Value
+		}
+	}
+	return value
+}
+
+// EnabledCodes returns the codes of the enabled cheats
+func (l *List) EnabledCodes() []string {
+	var codes []string
+	for _, c := range l.Cheats {
+		if c.Enabled {
+			codes = append(codes, c.Code)
+		}
+	}
+	return codes
+}
+
+// RamWrites returns the enabled GameShark codes
+func (l *List) RamWrites() []*Cheat {
+	return l.shark
+}
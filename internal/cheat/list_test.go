@@ -0,0 +1,68 @@
+package cheat
+
+import "testing"
+
+func newTestList(t *testing.T, codes ...string) *List {
+	t.Helper()
+	l := NewList("")
+	for _, code := range codes {
+		if _, err := l.Add(code, ""); err != nil {
+			t.Fatalf("Add(%q): %v", code, err)
+		}
+	}
+	return l
+}
+
+func TestPatchRead(t *testing.T) {
+	l := newTestList(t, "00A-17B-C49", "3E1-2FA")
+
+	tests := []struct {
+		name    string
+		address uint16
+		rom     byte
+		want    byte
+	}{
+		{"compare matches", 0x4A17, 0xC8, 0x00},
+		{"compare differs", 0x4A17, 0x12, 0x12},
+		{"no compare", 0x512F, 0x77, 0x3E},
+		{"other address", 0x4A18, 0xC8, 0xC8},
+	}
+	for _, tt := range tests {
+		if got := l.PatchRead(tt.address, tt.rom); got != tt.want {
+			t.Errorf("%s: PatchRead(%04X, %02X) = %02X, want %02X", tt.name, tt.address, tt.rom, got, tt.want)
+		}
+	}
+}
+
+func TestToggleRebuilds(t *testing.T) {
+	l := newTestList(t, "3E1-2FA", "01FF21D0", "8A05A0A0")
+
+	if got := l.PatchRead(0x512F, 0x77); got != 0x3E {
+		t.Fatalf("enabled Game Genie code not applied: %02X", got)
+	}
+	if n := len(l.RamWrites()); n != 2 {
+		t.Fatalf("got %d GameShark writes, want 2", n)
+	}
+
+	if !l.Toggle(0) || !l.Toggle(1) {
+		t.Fatal("Toggle of a listed cheat failed")
+	}
+	if got := l.PatchRead(0x512F, 0x77); got != 0x77 {
+		t.Errorf("disabled Game Genie code still applied: %02X", got)
+	}
+	if w := l.RamWrites(); len(w) != 1 || w[0].Code != "8A05A0A0" {
+		t.Errorf("RamWrites after toggle = %v", w)
+	}
+	if codes := l.EnabledCodes(); len(codes) != 1 || codes[0] != "8A05A0A0" {
+		t.Errorf("EnabledCodes = %v", codes)
+	}
+
+	l.Toggle(0)
+	if got := l.PatchRead(0x512F, 0x77); got != 0x3E {
+		t.Errorf("re-enabled Game Genie code not applied: %02X", got)
+	}
+
+	if l.Toggle(-1) || l.Toggle(3) {
+		t.Error("Toggle out of range reported success")
+	}
+}
@@ -3,6 +3,7 @@ package cpu
 import (
 	"app/internal/logger"
 	"app/internal/memory"
+	"app/internal/model"
 )
 
 type BootRomContext struct {
@@ -34,22 +35,62 @@ func (b *BootRomContext) DisableBootRom() {
 	b.BootRomEnabled = false
 }
 
+// bootState is what a model's boot ROM leaves behind at 0x100
+type bootState struct {
+	regs CpuRegisters // Games tell the models apart by A and B
+	div  uint16       // Internal DIV counter
+}
+
+// bootStates holds the post-boot state of each model. The DIV phase is
+// known on the DMG and MGB, and DIV reads 18 on the DMG0. The other boot
+// ROMs take a time that depends on the logo animation or the SGB handshake;
+// they get the DMG value as a placeholder. The CGB and AGB entries are for
+// DMG mode, where B and HL also depend on the title of Nintendo games; other
+// games' values are used.
+var bootStates = map[model.Model]bootState{
+	model.DMG0: {CpuRegisters{A: 0x01, F: 0x00, B: 0xFF, C: 0x13, D: 0x00, E: 0xC1, H: 0x84, L: 0x03}, 0x1800},
+	model.DMG:  {CpuRegisters{A: 0x01, F: 0xB0, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D}, 0xABCC},
+	model.MGB:  {CpuRegisters{A: 0xFF, F: 0xB0, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D}, 0xABCC},
+	model.SGB:  {CpuRegisters{A: 0x01, F: 0x00, B: 0x00, C: 0x14, D: 0x00, E: 0x00, H: 0xC0, L: 0x60}, 0xABCC},
+	model.SGB2: {CpuRegisters{A: 0xFF, F: 0x00, B: 0x00, C: 0x14, D: 0x00, E: 0x00, H: 0xC0, L: 0x60}, 0xABCC},
+	model.CGB:  {CpuRegisters{A: 0x11, F: 0x80, B: 0x00, C: 0x00, D: 0x00, E: 0x08, H: 0x00, L: 0x7C}, 0xABCC},
+	model.AGB:  {CpuRegisters{A: 0x11, F: 0x00, B: 0x01, C: 0x00, D: 0x00, E: 0x08, H: 0x00, L: 0x7C}, 0xABCC},
+}
+
+// bootRegisters returns the CPU registers the boot ROM of m leaves for the
+// inserted cartridge
+func bootRegisters(m model.Model) CpuRegisters {
+	regs := bootStates[m].regs
+	bus := memory.BusCtx()
+
+	switch {
+	case m == model.DMG || m == model.MGB:
+		// H and C are left set by the header checksum loop unless the
+		// checksum byte is 0
+		if bus.BusRead(0x014D) == 0 {
+			regs.F = 0x80
+		}
+	case m.IsCgb() && bus.BusRead(0x0143)&0x80 != 0:
+		// CGB mode isn't emulated: boot the cartridge in DMG mode, the way a
+		// CGB runs it with its CGB flag cleared, rather than hand it CGB mode
+		// registers on hardware that behaves like a DMG
+		logger.Warn("Boot ROM: CGB mode isn't emulated, running the cartridge in DMG mode")
+	}
+
+	regs.Sp = 0xFFFE
+	regs.Pc = 0x0100 // Start execution at ROM entry point
+	return regs
+}
+
 func (b *BootRomContext) SimulateBootSequence() {
-	logger.Info("Boot ROM: Simulating boot sequence")
+	m := model.Current()
+	logger.Info("Boot ROM: Simulating %s boot sequence", m)
 
 	b.loadNintendoLogoToVRAM()
 
 	cpu := CpuCtx()
-	cpu.Regs.A = 0x01 // DMG boot ROM sets A=01
-	cpu.Regs.F = 0xB0 // Z=1, N=0, H=1, C=1
-	cpu.Regs.B = 0x00
-	cpu.Regs.C = 0x13
-	cpu.Regs.D = 0x00
-	cpu.Regs.E = 0xD8
-	cpu.Regs.H = 0x01
-	cpu.Regs.L = 0x4D
-	cpu.Regs.Sp = 0xFFFE
-	cpu.Regs.Pc = 0x0100 // Start execution at ROM entry point
+	cpu.Regs = bootRegisters(m)
+	TimerCtx().div = bootStates[m].div
 
 	logger.Debug("Boot ROM: CPU registers initialized")
 	logger.Debug("Boot ROM: A=%02X F=%02X BC=%04X DE=%04X HL=%04X SP=%04X PC=%04X",
@@ -130,10 +171,14 @@ func (b *BootRomContext) initializeIORegisters() {
 
 	// Serial data (FF01-FF02)
 	memory.BusCtx().BusWrite(0xFF01, 0x00) // Serial transfer data
-	memory.BusCtx().BusWrite(0xFF02, 0x7E) // Serial transfer control
+	if model.Current().IsCgb() {
+		memory.BusCtx().BusWrite(0xFF02, 0x7F) // Serial transfer control, reads 7F
+	} else {
+		memory.BusCtx().BusWrite(0xFF02, 0x7E) // Serial transfer control
+	}
 
-	// Divider register (FF04) - initialized by timer
-	memory.BusCtx().BusWrite(0xFF04, 0x18) // DIV register (continuously incrementing)
+	// Divider register (FF04) is set with the CPU registers; writing it
+	// would reset it
 
 	// Sound registers will be handled separately
 
@@ -172,7 +217,11 @@ func (b *BootRomContext) initializeSoundRegisters() {
 	// Sound Control (FF24-FF26)
 	memory.BusCtx().BusWrite(0xFF24, 0x77) // NR50
 	memory.BusCtx().BusWrite(0xFF25, 0xF3) // NR51
-	memory.BusCtx().BusWrite(0xFF26, 0xF1) // NR52
+	if model.Current().IsSgb() {
+		memory.BusCtx().BusWrite(0xFF26, 0xF0) // NR52
+	} else {
+		memory.BusCtx().BusWrite(0xFF26, 0xF1) // NR52
+	}
 
 	// Wave Pattern RAM (FF30-FF3F) - initialized to specific pattern
 	wavePattern := []byte{
@@ -205,8 +254,7 @@ func (b *BootRomContext) initializeLCDRegisters() {
 	// LYC (FF45) - LY compare
 	memory.BusCtx().BusWrite(0xFF45, 0x00) // LY compare value
 
-	// DMA (FF46) - DMA transfer
-	memory.BusCtx().BusWrite(0xFF46, 0xFF) // No DMA transfer active
+	// DMA (FF46) already reads 0xFF; writing it would start a transfer
 
 	// Palette registers (FF47-FF49)
 	memory.BusCtx().BusWrite(0xFF47, 0xFC) // BGP - background palette
@@ -234,8 +282,8 @@ func (b *BootRomContext) initializeTimerRegisters() {
 func (b *BootRomContext) initializeInterruptRegisters() {
 	logger.Debug("Boot ROM: Initializing interrupt registers")
 
-	// Interrupt registers - clear both IF and IE initially
-	memory.BusCtx().BusWrite(0xFF0F, 0x00) // IF - no interrupt flags set
+	// The boot ROM leaves V-blank pending and no interrupts enabled
+	memory.BusCtx().BusWrite(0xFF0F, 0x01) // IF - reads E1
 	memory.BusCtx().BusWrite(0xFFFF, 0x00) // IE - no interrupts enabled
 
 	logger.Debug("Boot ROM: Interrupt registers initialized")
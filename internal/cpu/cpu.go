@@ -38,6 +38,15 @@ type Bus interface {
 	BusWrite(address uint16, data byte)
 }
 
+// OamBug receives the CPU accesses that corrupt OAM on the DMG: bus accesses
+// to 0xFE00-0xFEFF, and 16-bit increments and decrements of addresses there,
+// during the OAM scan
+type OamBug interface {
+	OamBugWrite(address uint16)        // Write, or increment/decrement
+	OamBugRead(address uint16)         // Read
+	OamBugReadIncrease(address uint16) // Read and increment/decrement in the same cycle
+}
+
 type CPU interface {
 	Fetch()
 	Step() bool
@@ -75,21 +84,21 @@ type CpuContext struct {
 	currentInst *Instruction
 
 	Halted   bool
+	haltBug  bool // The next opcode fetch doesn't increment PC
 	Stopped  bool
 	Stepping bool
 
 	IntMasterEnabled bool
-	enablingIme      bool
+	eiState          eiDelay
 	iERegister       byte
 	IntFlags         byte
 	memoryBus        Bus
+	oamBug           OamBug // nil unless OAM corruption is emulated
 }
 
 var cpuInstance *CpuContext
 
 func NewCpuContext(memoryBus Bus) *CpuContext {
-
-	//TimerCtx().div = 0xABCC TODO
 	InitInstructions()
 	InitProcessors()
 	cpuInstance = &CpuContext{
@@ -114,7 +123,7 @@ func NewCpuContext(memoryBus Bus) *CpuContext {
 		Stopped:          false,
 		Stepping:         false,
 		IntMasterEnabled: false,
-		enablingIme:      false,
+		eiState:          eiIdle,
 		iERegister:       0,
 		IntFlags:         0,
 		memoryBus:        memoryBus,
@@ -122,6 +131,11 @@ func NewCpuContext(memoryBus Bus) *CpuContext {
 	return cpuInstance
 }
 
+// SetOamBug enables OAM corruption, reported to b. nil disables it.
+func (c *CpuContext) SetOamBug(b OamBug) {
+	c.oamBug = b
+}
+
 func CpuCtx() *CpuContext {
 	if cpuInstance == nil {
 		logger.Fatal("Create new Cpu with NewCpuContext")
@@ -131,8 +145,12 @@ func CpuCtx() *CpuContext {
 }
 
 func (c *CpuContext) Fetch() {
-	c.CurOpCode = c.memoryBus.BusRead(c.Regs.Pc)
-	c.Regs.Pc++
+	c.CurOpCode = busRead(c.Regs.Pc)
+	if c.haltBug {
+		c.haltBug = false
+	} else {
+		c.Regs.Pc++
+	}
 	c.currentInst = instructionByOpcode(c.CurOpCode)
 }
 
@@ -145,15 +163,39 @@ func (c *CpuContext) Execute() {
 	proc(c)
 }
 
-// This should probably not call the emulator
+// eiDelay tracks EI's one-instruction delay: IME is set once the
+// instruction after EI completes, so EI; DI never services an interrupt
+type eiDelay byte
+
+const (
+	eiIdle     eiDelay = iota
+	eiExecuted         // EI ran in this step
+	eiArmed            // The instruction after EI is running
+)
+
+// advanceIme moves the EI delay on at the end of an instruction
+func (c *CpuContext) advanceIme() {
+	switch c.eiState {
+	case eiExecuted:
+		c.eiState = eiArmed
+	case eiArmed:
+		c.eiState = eiIdle
+		c.IntMasterEnabled = true
+		logger.Debug("IME enabled at PC=%04X", c.Regs.Pc)
+	}
+}
+
+// Step runs one instruction, or one idle stretch while halted, and then
+// dispatches a pending interrupt if IME is set
 func (c *CpuContext) Step() bool {
 	if c.Stopped {
-		return false
+		// STOP: low-power mode, nothing runs until a joypad line goes low
+		Cm.IncreaseStoppedCycle(1)
+		return true
 	}
 
 	if !c.Halted {
 		c.Fetch()
-		Cm.IncreaseCycle(1)
 		FetchData()
 
 		if c.currentInst == nil {
@@ -167,27 +209,24 @@ func (c *CpuContext) Step() bool {
 		}
 
 		c.Execute()
+		c.advanceIme()
 	} else {
-		Cm.IncreaseCycle(1)
-		if c.IntFlags != 0 {
+		// HALT ends once IE & IF != 0, whatever IME is. With IME set the
+		// dispatch below wakes the CPU.
+		Cm.SkipIdle()
+		if !c.IntMasterEnabled && c.pendingInterrupts() != 0 {
 			c.Halted = false
 		}
 	}
 
 	if c.Stopped {
-		return false
+		// Just entered STOP; interrupts are serviced after wakeup
+		return true
 	}
 
-	// Handle interrupts AFTER instruction execution (reference implementation order)
+	// Interrupts are dispatched between instructions
 	if c.IntMasterEnabled {
 		CpuHandleInterrupts(c)
-		c.enablingIme = false
-	}
-
-	// Handle EI instruction: enable interrupts now if EI was executed
-	if c.enablingIme {
-		logger.Debug("IME enabled at PC=%04X", c.Regs.Pc)
-		c.IntMasterEnabled = true
 	}
 
 	return true
@@ -204,6 +243,12 @@ func (c *CpuContext) SetIERegister(n byte) {
 
 func (c *CpuContext) RequestInterrupt(t InterruptType) {
 	c.IntFlags |= byte(t)
+
+	// A joypad line going low ends STOP mode, regardless of IE and IME
+	if t == IT_JOYPAD && c.Stopped {
+		c.Stopped = false
+		logger.Debug("Joypad input: waking CPU from STOP at PC=%04X", c.Regs.Pc)
+	}
 }
 
 func (c *CpuContext) IsStopped() bool {
@@ -2,7 +2,6 @@ package cpu
 
 import (
 	logger "app/internal/logger"
-	"app/internal/memory"
 )
 
 /*
@@ -36,16 +35,13 @@ func FetchData() {
 		return
 	case AM_R_D8, AM_D8:
 		// Immediate 8-bit data. Correct, but should check for signedness in JR r8 (signed offset).
-		cpuInstance.FetchedData = uint16(memory.BusCtx().BusRead(cpuInstance.Regs.Pc)) & 0xFF
-		Cm.IncreaseCycle(1)
+		cpuInstance.FetchedData = uint16(busRead(cpuInstance.Regs.Pc)) & 0xFF
 		cpuInstance.Regs.Pc++
 		return
 	case AM_R_D16, AM_D16:
 		// Immediate 16-bit data. Correct for LD r,nn and similar.
-		var lo = uint16(memory.BusCtx().BusRead(cpuInstance.Regs.Pc))
-		Cm.IncreaseCycle(1)
-		var hi = uint16(memory.BusCtx().BusRead(cpuInstance.Regs.Pc + 1))
-		Cm.IncreaseCycle(1)
+		var lo = uint16(busRead(cpuInstance.Regs.Pc))
+		var hi = uint16(busRead(cpuInstance.Regs.Pc + 1))
 		cpuInstance.FetchedData = lo | (hi << 8)
 		cpuInstance.Regs.Pc += 2
 		return
@@ -64,21 +60,18 @@ func FetchData() {
 		if cpuInstance.currentInst.Reg2 == RT_C {
 			addr |= 0xFF00 // For LD A,(C). OK.
 		}
-		cpuInstance.FetchedData = uint16(memory.BusCtx().BusRead(addr)) & 0xFF
-		Cm.IncreaseCycle(1)
+		cpuInstance.FetchedData = uint16(busRead(addr)) & 0xFF
 		return
 	case AM_R_HLI:
 		// LD r,(HL+). FetchedData = (HL), then HL++.
 		addr := CpuRegRead(RT_HL)
-		cpuInstance.FetchedData = uint16(memory.BusCtx().BusRead(addr)) & 0xFF
-		Cm.IncreaseCycle(1)
+		cpuInstance.FetchedData = uint16(busReadIncrease(addr)) & 0xFF
 		CpuSetReg(RT_HL, addr+1)
 		return
 	case AM_R_HLD:
 		// LD r,(HL-). FetchedData = (HL), then HL--.
 		addr := CpuRegRead(RT_HL)
-		cpuInstance.FetchedData = uint16(memory.BusCtx().BusRead(addr)) & 0xFF
-		Cm.IncreaseCycle(1)
+		cpuInstance.FetchedData = uint16(busReadIncrease(addr)) & 0xFF
 		CpuSetReg(RT_HL, addr-1)
 		return
 	case AM_HLI_R:
@@ -96,29 +89,24 @@ func FetchData() {
 		CpuSetReg(RT_HL, cpuInstance.MemDest-1)
 		return
 	case AM_R_A8:
-		cpuInstance.FetchedData = uint16(memory.BusCtx().BusRead(cpuInstance.Regs.Pc))
-		Cm.IncreaseCycle(1)
+		cpuInstance.FetchedData = uint16(busRead(cpuInstance.Regs.Pc))
 		cpuInstance.Regs.Pc++
 		return
 	case AM_A8_R:
-		cpuInstance.MemDest = uint16(memory.BusCtx().BusRead(cpuInstance.Regs.Pc)) | 0xFF00
+		cpuInstance.MemDest = uint16(busRead(cpuInstance.Regs.Pc)) | 0xFF00
 		cpuInstance.DestIsMem = true
-		Cm.IncreaseCycle(1)
 		cpuInstance.Regs.Pc++
 		return
 	case AM_HL_SPR:
 		// LD HL,SP+e8. Fetch raw signed offset byte; actual addition is handled in processor.
-		offset := memory.BusCtx().BusRead(cpuInstance.Regs.Pc)
-		Cm.IncreaseCycle(1)
+		offset := busRead(cpuInstance.Regs.Pc)
 		cpuInstance.Regs.Pc++
 		cpuInstance.FetchedData = uint16(offset)
 		return
 	case AM_A16_R, AM_D16_R:
 		// LD (a16),r. FetchedData = r, MemDest = a16.
-		var lo = uint16(memory.BusCtx().BusRead(cpuInstance.Regs.Pc))
-		Cm.IncreaseCycle(1)
-		var hi = uint16(memory.BusCtx().BusRead(cpuInstance.Regs.Pc + 1))
-		Cm.IncreaseCycle(1)
+		var lo = uint16(busRead(cpuInstance.Regs.Pc))
+		var hi = uint16(busRead(cpuInstance.Regs.Pc + 1))
 		cpuInstance.Regs.Pc += 2
 		cpuInstance.MemDest = lo | (hi << 8)
 		cpuInstance.DestIsMem = true
@@ -130,8 +118,7 @@ func FetchData() {
 		return
 	case AM_MR_D8:
 		// LD (reg),d8. FetchedData = d8, MemDest = reg.
-		cpuInstance.FetchedData = uint16(memory.BusCtx().BusRead(cpuInstance.Regs.Pc)) & 0xFF
-		Cm.IncreaseCycle(1)
+		cpuInstance.FetchedData = uint16(busRead(cpuInstance.Regs.Pc)) & 0xFF
 		cpuInstance.Regs.Pc++
 		cpuInstance.MemDest = CpuRegRead(cpuInstance.currentInst.Reg1)
 		cpuInstance.DestIsMem = true
@@ -140,19 +127,15 @@ func FetchData() {
 		// INC/DEC (reg). FetchedData = (reg), MemDest = reg.
 		cpuInstance.MemDest = CpuRegRead(cpuInstance.currentInst.Reg1)
 		cpuInstance.DestIsMem = true
-		cpuInstance.FetchedData = uint16(memory.BusCtx().BusRead(cpuInstance.MemDest)) & 0xFF
-		Cm.IncreaseCycle(1)
+		cpuInstance.FetchedData = uint16(busRead(cpuInstance.MemDest)) & 0xFF
 		return
 	case AM_R_A16:
 		// LD r,(a16). FetchedData = (a16).
-		var lo = uint16(memory.BusCtx().BusRead(cpuInstance.Regs.Pc))
-		Cm.IncreaseCycle(1)
-		var hi = uint16(memory.BusCtx().BusRead(cpuInstance.Regs.Pc + 1))
-		Cm.IncreaseCycle(1)
+		var lo = uint16(busRead(cpuInstance.Regs.Pc))
+		var hi = uint16(busRead(cpuInstance.Regs.Pc + 1))
 		cpuInstance.Regs.Pc += 2
 		addr := lo | (hi << 8)
-		cpuInstance.FetchedData = uint16(memory.BusCtx().BusRead(addr)) & 0xFF
-		Cm.IncreaseCycle(1)
+		cpuInstance.FetchedData = uint16(busRead(addr)) & 0xFF
 		return
 	default:
 		// Fault: Unknown addressing mode. Should not happen if instruction table is correct.
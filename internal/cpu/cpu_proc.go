@@ -3,7 +3,6 @@ package cpu
 import (
 	"app/internal/common"
 	"app/internal/logger"
-	"app/internal/memory"
 )
 
 var (
@@ -42,13 +41,13 @@ func (c *CpuContext) WriteRegDE(value uint16) {
 }
 
 func ProcINC16(cpu *CpuContext, reg16 *uint16) {
+	iduCycle(*reg16) // 16-bit increment takes 2 cycles total
 	*reg16++
-	Cm.IncreaseCycle(1) // 16-bit increment takes 2 cycles total
 }
 
 func ProcDEC16(cpu *CpuContext, reg16 *uint16) {
+	iduCycle(*reg16) // 16-bit decrement takes 2 cycles total
 	*reg16--
-	Cm.IncreaseCycle(1) // 16-bit decrement takes 2 cycles total
 }
 
 func ProcADD_HL(cpu *CpuContext, value uint16) {
@@ -63,7 +62,7 @@ func ProcADD_HL(cpu *CpuContext, value uint16) {
 
 	CpuSetFlags(cpu, &z, &n, &h, &c)
 	cpu.WriteRegHL(uint16(result & 0xFFFF))
-	Cm.IncreaseCycle(1) // ADD HL takes 2 cycles total
+	internalCycle() // ADD HL takes 2 cycles total
 }
 
 func procNone(ctx *CpuContext) {
@@ -97,13 +96,12 @@ func procLd(ctx *CpuContext) {
 	if ctx.DestIsMem {
 		if is16bit(ctx.currentInst.Reg2) {
 			logger.Debug("LD mem16: opcode=%02X dest=%04X fetched=%04X srcReg=%d spNow=%04X", ctx.CurOpCode, ctx.MemDest, ctx.FetchedData, ctx.currentInst.Reg2, CpuRegRead(RT_SP))
-			// Fault: 16-bit memory writes are rare (only LD (a16),SP). Make sure this is only used for correct instructions.
-			Cm.IncreaseCycle(1)
-			memory.BusCtx().BusWrite16(ctx.MemDest, ctx.FetchedData)
+			// Only LD (a16),SP writes 16 bits: low byte first
+			busWrite(ctx.MemDest, byte(ctx.FetchedData))
+			busWrite(ctx.MemDest+1, byte(ctx.FetchedData>>8))
 		} else {
-			memory.BusCtx().BusWrite(ctx.MemDest, byte(ctx.FetchedData))
+			busWrite(ctx.MemDest, byte(ctx.FetchedData))
 		}
-		Cm.IncreaseCycle(1)
 		return
 	}
 
@@ -142,7 +140,7 @@ func procLd(ctx *CpuContext) {
 			logger.Warn("LD HL,SP+e8 mutated SP unexpectedly: before=%04X after=%04X offset=%d", sp, spAfter, offset)
 		}
 
-		Cm.IncreaseCycle(1)
+		internalCycle()
 		return
 	}
 
@@ -153,6 +151,7 @@ func procLd(ctx *CpuContext) {
 	CpuSetReg(ctx.currentInst.Reg1, ctx.FetchedData)
 
 	if ctx.currentInst.Mode == AM_R_R && ctx.currentInst.Reg1 == RT_SP && ctx.currentInst.Reg2 == RT_HL {
+		internalCycle()
 		if debugLdSpCount < 32 {
 			debugLdSpCount++
 			logger.Debug("LD SP,HL debug: HL=%04X -> SP=%04X", CpuRegRead(RT_HL), CpuRegRead(RT_SP))
@@ -165,12 +164,9 @@ func procCb(ctx *CpuContext) {
 	reg := decodeReg(op & 0b111)
 	bit := (op >> 3) & 0b111
 	bitOp := (op >> 6) & 0b11
+	// (HL) operands are read and written through CpuRegRead8/CpuSetReg8,
+	// one M-cycle each
 	regval := CpuRegRead8(reg)
-	Cm.IncreaseCycle(1)
-
-	if reg == RT_HL {
-		Cm.IncreaseCycle(2)
-	}
 
 	switch bitOp {
 	case 0:
@@ -418,20 +414,20 @@ func procCp(ctx *CpuContext) {
 }
 
 func procDi(ctx *CpuContext) {
-	// DI: Disable interrupts
+	// DI: Disable interrupts, cancelling a pending EI
 	ctx.IntMasterEnabled = false
+	ctx.eiState = eiIdle
 }
 
 func procEi(ctx *CpuContext) {
-	// EI: Enable interrupts after next instruction
+	// EI: Enable interrupts after the next instruction
 	logger.Debug("procEi invoked at PC=%04X", ctx.Regs.Pc)
-	ctx.enablingIme = true
+	ctx.eiState = eiExecuted
 }
 
 func procPop(ctx *CpuContext) {
 	// POP rr: Pop two bytes from stack into register pair
 	n := StackPop16()
-	Cm.IncreaseCycle(2) // StackPop16 already handles the memory accesses
 	CpuSetReg(ctx.currentInst.Reg1, n)
 	if ctx.currentInst.Reg1 == RT_AF {
 		// Lower 4 bits of F always zero
@@ -442,9 +438,8 @@ func procPop(ctx *CpuContext) {
 func procPush(ctx *CpuContext) {
 	// PUSH rr: Push register pair onto stack
 	value := CpuRegRead(ctx.currentInst.Reg1)
-	Cm.IncreaseCycle(1)
+	iduCycle(ctx.Regs.Sp)
 	StackPush16(value)
-	Cm.IncreaseCycle(1)
 }
 
 func goToAddr(ctx *CpuContext, addr uint16, pushpc bool) {
@@ -455,13 +450,13 @@ func goToAddr(ctx *CpuContext, addr uint16, pushpc bool) {
 			value := ctx.Regs.Pc
 			hi := byte((value >> 8) & 0xFF)
 			lo := byte(value & 0xFF)
-			Cm.IncreaseCycle(1)
+			iduCycle(ctx.Regs.Sp)
 			StackPush(hi)
-			Cm.IncreaseCycle(1)
 			StackPush(lo)
+		} else {
+			internalCycle()
 		}
 		ctx.Regs.Pc = addr
-		Cm.IncreaseCycle(1)
 	}
 }
 
@@ -469,7 +464,9 @@ func procJp(ctx *CpuContext) {
 	// JP nn or JP cc,nn: Jump to address
 	if CheckCondition(ctx) {
 		ctx.Regs.Pc = ctx.FetchedData
-		Cm.IncreaseCycle(1) // Jump cycle
+		if ctx.currentInst.Mode != AM_R {
+			internalCycle() // JP HL loads PC without an extra cycle
+		}
 	}
 }
 
@@ -479,7 +476,7 @@ func procJr(ctx *CpuContext) {
 		rel := int8(ctx.FetchedData)
 		addr := uint16(int32(ctx.Regs.Pc) + int32(rel))
 		ctx.Regs.Pc = addr
-		Cm.IncreaseCycle(1) // Jump cycle
+		internalCycle() // Jump cycle
 	}
 }
 
@@ -487,11 +484,10 @@ func procCall(ctx *CpuContext) {
 	// CALL nn or CALL cc,nn: Call subroutine
 	if CheckCondition(ctx) {
 		// Push current PC to stack
-		Cm.IncreaseCycle(1)
+		iduCycle(ctx.Regs.Sp)
 		StackPush16(ctx.Regs.Pc)
 		// Jump to new address
 		ctx.Regs.Pc = ctx.FetchedData
-		Cm.IncreaseCycle(1)
 	}
 }
 
@@ -499,7 +495,7 @@ func procCall(ctx *CpuContext) {
 func procRet(ctx *CpuContext) {
 	// RET or RET cc: Return from subroutine
 	if ctx.currentInst.Condition != CT_NONE {
-		Cm.IncreaseCycle(1) // Conditional check takes 1 cycle
+		internalCycle() // Conditional check takes 1 cycle
 		if !CheckCondition(ctx) {
 			return // Condition not met, don't return
 		}
@@ -507,23 +503,22 @@ func procRet(ctx *CpuContext) {
 
 	// Pop return address from stack
 	ctx.Regs.Pc = StackPop16()
-	Cm.IncreaseCycle(2) // StackPop16 handles memory access cycles
-	Cm.IncreaseCycle(1) // Jump cycle
+	internalCycle() // Jump cycle
 }
 
 func procRst(ctx *CpuContext) {
 	// RST vec: Call fixed address (push PC, jump to vec)
 	// Push current PC to stack
-	Cm.IncreaseCycle(1)
+	iduCycle(ctx.Regs.Sp)
 	StackPush16(ctx.Regs.Pc)
 	// Jump to RST vector
 	ctx.Regs.Pc = uint16(ctx.currentInst.Param)
-	Cm.IncreaseCycle(1)
 }
 
 func procReti(ctx *CpuContext) {
-	// RETI: Return and enable interrupts (enable FIRST like reference implementation)
+	// RETI: Return and enable interrupts at once, without EI's delay
 	ctx.IntMasterEnabled = true
+	ctx.eiState = eiIdle
 	procRet(ctx)
 }
 
@@ -532,22 +527,20 @@ func procLdh(ctx *CpuContext) {
 	if ctx.currentInst.Reg1 == RT_A {
 		// LDH A,(a8) - read from high RAM
 		addr := 0xFF00 | (ctx.FetchedData & 0xFF)
-		ctx.Regs.A = memory.BusCtx().BusRead(addr)
+		ctx.Regs.A = busRead(addr)
 	} else {
 		// LDH (a8),A - write to high RAM
 		// For AM_A8_R, mem_dest is already set in fetch_data
-		memory.BusCtx().BusWrite(ctx.MemDest, ctx.Regs.A)
+		busWrite(ctx.MemDest, ctx.Regs.A)
 	}
-	Cm.IncreaseCycle(1)
 }
 
 func procInc(ctx *CpuContext) {
 	if ctx.currentInst.Mode == AM_MR {
-		addr := CpuRegRead(RT_HL)
-		old := memory.BusCtx().BusRead(addr)
+		// FetchData already read (HL)
+		old := byte(ctx.FetchedData)
 		value := uint16(old) + 1
-		memory.BusCtx().BusWrite(addr, byte(value&0xFF))
-		Cm.IncreaseCycle(1)
+		busWrite(ctx.MemDest, byte(value&0xFF))
 
 		z := (value & 0xFF) == 0
 		n := false
@@ -566,7 +559,7 @@ func procInc(ctx *CpuContext) {
 			regs := CpuGetRegs()
 			logger.Debug("INC SP debug: before=%04X after=%04X AF=%02X%02X BC=%02X%02X DE=%02X%02X HL=%02X%02X", before, regs.Sp, regs.A, regs.F, regs.B, regs.C, regs.D, regs.E, regs.H, regs.L)
 		}
-		Cm.IncreaseCycle(1)
+		iduCycle(before)
 		return
 	}
 
@@ -583,11 +576,10 @@ func procInc(ctx *CpuContext) {
 
 func procDec(ctx *CpuContext) {
 	if ctx.currentInst.Mode == AM_MR {
-		addr := CpuRegRead(RT_HL)
-		old := memory.BusCtx().BusRead(addr)
+		// FetchData already read (HL)
+		old := byte(ctx.FetchedData)
 		value := uint16(old) - 1
-		memory.BusCtx().BusWrite(addr, byte(value&0xFF))
-		Cm.IncreaseCycle(1)
+		busWrite(ctx.MemDest, byte(value&0xFF))
 
 		z := (value & 0xFF) == 0
 		n := true
@@ -598,9 +590,9 @@ func procDec(ctx *CpuContext) {
 	}
 
 	if is16bit(ctx.currentInst.Reg1) {
-		value := CpuRegRead(ctx.currentInst.Reg1) - 1
-		CpuSetReg(ctx.currentInst.Reg1, value)
-		Cm.IncreaseCycle(1)
+		before := CpuRegRead(ctx.currentInst.Reg1)
+		CpuSetReg(ctx.currentInst.Reg1, before-1)
+		iduCycle(before)
 		return
 	}
 
@@ -689,7 +681,8 @@ func procAdd(ctx *CpuContext) {
 			expectedC := ((sp & 0x00FF) + uint16(byte(offset))) > 0x00FF
 			logger.Debug("ADD SP,e8 debug: SP=%04X offset=%d result=%04X H=%t/%t C=%t/%t F=%02X", sp, offset, result, h, expectedH, c, expectedC, flags)
 		}
-		Cm.IncreaseCycle(2)
+		internalCycle()
+		internalCycle()
 		return
 	}
 
@@ -706,7 +699,7 @@ func procAdd(ctx *CpuContext) {
 
 		CpuSetReg(ctx.currentInst.Reg1, result&0xFFFF)
 		CpuSetFlags(ctx, nil, &n, &h, &c)
-		Cm.IncreaseCycle(1)
+		internalCycle()
 		return
 	}
 
@@ -724,10 +717,17 @@ func procAdd(ctx *CpuContext) {
 }
 
 func procStop(ctx *CpuContext) {
-	// STOP: Enter low-power mode (not fully emulated here)
-	logger.Debug("STOP instruction encountered; halting CPU")
-	ctx.Halted = true
-	//ctx.Stopped = true
+	// STOP: Enter low-power mode until a joypad line goes low.
+	// STOP is encoded as 10 00, the padding byte is skipped.
+	ctx.Regs.Pc++
+
+	// Entering STOP resets DIV
+	Cm.Sync()
+	TimerCtx().Write(0xFF04, 0)
+	Cm.Sync()
+
+	logger.Debug("STOP instruction encountered; entering low-power mode")
+	ctx.Stopped = true
 }
 
 func procDaa(ctx *CpuContext) {
@@ -815,7 +815,13 @@ func procCcf(ctx *CpuContext) {
 }
 
 func procHalt(ctx *CpuContext) {
-	// HALT: Enter low-power mode (not fully emulated here)
+	// HALT: sleep until IE & IF != 0. With IME clear and an interrupt already
+	// pending the CPU doesn't halt, and the HALT bug makes it read the next
+	// byte twice.
+	if !ctx.IntMasterEnabled && ctx.pendingInterrupts() != 0 {
+		ctx.haltBug = true
+		return
+	}
 	ctx.Halted = true
 }
 
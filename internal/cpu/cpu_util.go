@@ -3,7 +3,6 @@ package cpu
 import (
 	"app/internal/common"
 	logger "app/internal/logger"
-	"app/internal/memory"
 )
 
 func CpuFlagZ() bool {
@@ -99,6 +98,61 @@ func CpuSetReg(regType regTypes, val uint16) {
 	}
 }
 
+// busRead is a CPU memory read. It takes one M-cycle, and the components
+// are brought up to date before the access.
+func busRead(addr uint16) byte {
+	Cm.IncreaseCycle(1)
+	Cm.syncForAccess(addr)
+	if oamBugAddress(addr) {
+		cpuInstance.oamBug.OamBugRead(addr)
+	}
+	return cpuInstance.memoryBus.BusRead(addr)
+}
+
+// busReadIncrease is a read in which the IDU also increments or decrements
+// addr, as in LD A,(HL+) and POP
+func busReadIncrease(addr uint16) byte {
+	Cm.IncreaseCycle(1)
+	Cm.syncForAccess(addr)
+	if oamBugAddress(addr) {
+		cpuInstance.oamBug.OamBugReadIncrease(addr)
+	}
+	return cpuInstance.memoryBus.BusRead(addr)
+}
+
+// busWrite is a CPU memory write, timed like busRead. The components are
+// synced again afterwards, since the write may move their next event.
+func busWrite(addr uint16, data byte) {
+	Cm.IncreaseCycle(1)
+	Cm.syncForAccess(addr)
+	if oamBugAddress(addr) {
+		cpuInstance.oamBug.OamBugWrite(addr)
+	}
+	cpuInstance.memoryBus.BusWrite(addr, data)
+	Cm.syncForAccess(addr)
+}
+
+// internalCycle is an M-cycle in which the CPU does not access the bus
+func internalCycle() {
+	Cm.IncreaseCycle(1)
+}
+
+// iduCycle is an internal M-cycle in which the IDU increments or decrements
+// addr, as in INC rr or the SP decrement before a push
+func iduCycle(addr uint16) {
+	Cm.IncreaseCycle(1)
+	if oamBugAddress(addr) {
+		Cm.syncForAccess(addr)
+		cpuInstance.oamBug.OamBugWrite(addr)
+	}
+}
+
+// oamBugAddress reports whether OAM corruption is emulated and addr can
+// trigger it
+func oamBugAddress(addr uint16) bool {
+	return cpuInstance.oamBug != nil && addr >= 0xFE00 && addr < 0xFF00
+}
+
 // CpuRegRead8: Reads 8-bit register or memory at HL. For F, only upper nibble is valid.
 func CpuRegRead8(rt regTypes) byte {
 	switch rt {
@@ -119,8 +173,7 @@ func CpuRegRead8(rt regTypes) byte {
 	case RT_L:
 		return cpuInstance.Regs.L
 	case RT_HL:
-		addr := CpuRegRead(RT_HL)
-		return memory.BusCtx().BusRead(addr)
+		return busRead(CpuRegRead(RT_HL))
 	case RT_NONE:
 		// Reference implementation allows RT_NONE but doesn't return a value
 		return 0
@@ -150,8 +203,7 @@ func CpuSetReg8(rt regTypes, val byte) {
 	case RT_L:
 		cpuInstance.Regs.L = val
 	case RT_HL:
-		addr := CpuRegRead(RT_HL)
-		memory.BusCtx().BusWrite(addr, val)
+		busWrite(CpuRegRead(RT_HL), val)
 	case RT_NONE:
 		// Reference implementation allows RT_NONE but doesn't set anything
 		// Just ignore the operation
@@ -174,5 +226,8 @@ func CpuSetIntFlags(value byte) {
 }
 
 func CpuRequestInterrupt(it InterruptType) {
+	if cpuInstance == nil {
+		return
+	}
 	cpuInstance.RequestInterrupt(it)
 }
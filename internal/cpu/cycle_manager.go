@@ -1,22 +1,105 @@
 package cpu
 
+// CycleManager is the system clock. It counts M-cycles and runs the timer,
+// PPU and DMA lazily: each one is only synced when its next event is due or
+// when the CPU accesses memory it can observe, so the CPU still sees the
+// exact hardware state of every M-cycle.
 type CycleManager struct {
-	ticks int32
+	ticks int64 // M-cycles, including those spent in STOP
+	now   int64 // Dots the components have been clocked for
+
+	sched      Scheduler
+	components [numEvents]Component
+
+	deadline int64 // HALT does not skip past this tick count
 }
 
 var Cm = &CycleManager{}
 
+// Attach connects the PPU, DMA and the current timer to the clock. Either of
+// ppu and dma may be nil. Call it after Reset.
+func (c *CycleManager) Attach(ppu Component, dma Component) {
+	c.components[EventPpu] = ppu
+	c.components[EventTimer] = TimerCtx()
+	c.components[EventDma] = dma
+	c.sched.reset()
+}
+
+// IncreaseCycle advances the clock and runs any events that became due.
+// There is no serial port or APU yet; they belong in the scheduler once they
+// exist.
 func (c *CycleManager) IncreaseCycle(tickAmount int32) {
-	c.ticks += tickAmount
+	c.ticks += int64(tickAmount)
+	c.now += 4 * int64(tickAmount)
+	if c.now >= c.sched.Next() {
+		c.runEvents()
+	}
+}
 
-	// Batch advance the timer for better performance
-	if tickAmount > 0 {
-		timer := TimerCtx()
-		totalTicks := tickAmount * 4
-		timer.TickBatch(totalTicks)
+func (c *CycleManager) runEvents() {
+	for kind, comp := range c.components {
+		if !c.sched.Due(EventKind(kind), c.now) {
+			continue
+		}
+		if comp == nil {
+			c.sched.Schedule(EventKind(kind), Never)
+			continue
+		}
+		c.sched.Schedule(EventKind(kind), comp.Sync(c.now))
 	}
 }
 
-func (c *CycleManager) GetCycleTicks() int32 {
+// Sync brings every component up to the current time and reschedules its
+// next event
+func (c *CycleManager) Sync() {
+	for kind, comp := range c.components {
+		if comp != nil {
+			c.sched.Schedule(EventKind(kind), comp.Sync(c.now))
+		}
+	}
+}
+
+// syncForAccess syncs the components before a CPU access that can observe
+// them: VRAM, OAM and IO, or any address while OAM DMA is running
+func (c *CycleManager) syncForAccess(addr uint16) {
+	if addr&0xE000 == 0x8000 || (addr >= 0xFE00 && addr < 0xFF80) || c.sched.at[EventDma] != Never {
+		c.Sync()
+	}
+}
+
+// SkipIdle advances the clock while the CPU is halted: straight to the M-cycle
+// of the next event, but at least one M-cycle and not past the deadline
+func (c *CycleManager) SkipIdle() {
+	steps := int64(1)
+	if limit := c.deadline - c.ticks; limit > 1 {
+		steps = min((c.sched.Next()-c.now+3)/4, limit)
+		steps = max(steps, 1)
+	}
+	c.IncreaseCycle(int32(steps))
+}
+
+// SetDeadline limits how far SkipIdle may jump, so a frame ends on the tick
+// it would have with one M-cycle per halted step
+func (c *CycleManager) SetDeadline(ticks int64) {
+	c.deadline = ticks
+}
+
+// Reset sets the clock back to zero and detaches the components for a new
+// emulator instance
+func (c *CycleManager) Reset() {
+	c.ticks = 0
+	c.now = 0
+	c.deadline = 0
+	c.components = [numEvents]Component{}
+	c.sched.reset()
+}
+
+func (c *CycleManager) GetCycleTicks() int64 {
 	return c.ticks
 }
+
+// IncreaseStoppedCycle advances time while the CPU is in STOP mode. The
+// system clock is halted, so the timer, DIV and PPU do not advance.
+func (c *CycleManager) IncreaseStoppedCycle(tickAmount int32) {
+	c.ticks += int64(tickAmount)
+}
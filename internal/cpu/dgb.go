@@ -121,7 +121,7 @@ func DbgPrint() bool {
 						cpuInstance.IntFlags,
 						memory.BusCtx().GetInterruptEnable(),
 						cpuInstance.IntMasterEnabled,
-						cpuInstance.enablingIme,
+						cpuInstance.eiState != eiIdle,
 						sp-2, low,
 						sp-1, high,
 					)
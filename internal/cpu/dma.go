@@ -7,45 +7,75 @@ import (
 
 type DMA interface {
 	DMATick()
-	DMATickBatch(ticks int32)
 	DMATransferring() bool
 }
 
 var dmaInstance *DMAContext
 
+// DMAContext is the OAM DMA unit. Writing FF46 starts a transfer of 160
+// bytes from page value to OAM, one byte per M-cycle after one M-cycle of
+// setup. While bytes are copied the transfer owns the bus its source is on,
+// see memory.Bus.
 type DMAContext struct {
-	active      bool
+	active      bool // A transfer was started and has not finished
+	running     bool // Bytes are being copied, CPU accesses conflict with the transfer
 	currentByte byte
-	value       byte
+	value       byte // FF46, the source page
 	startDelay  byte
+	data        byte // Byte copied in the current M-cycle
+
+	lastSync int64 // Time the DMA was last brought up to date, in dots
 }
 
-func NewDMAContext(start byte) *DMAContext {
+// NewDMAContext creates an idle DMA unit. FF46 reads 0xFF after boot.
+func NewDMAContext() *DMAContext {
 	return &DMAContext{
-		active:      true,
-		currentByte: 0,
-		value:       start,
-		startDelay:  2,
+		value:    0xFF,
+		lastSync: Cm.now,
 	}
 }
 
+// ResetDmaCtx replaces the singleton with a fresh DMA context
+func ResetDmaCtx() *DMAContext {
+	dmaInstance = NewDMAContext()
+	return dmaInstance
+}
+
 func DmaCtx() *DMAContext {
 	if dmaInstance == nil {
-		dmaInstance = NewDMAContext(0)
+		dmaInstance = NewDMAContext()
 	}
 	return dmaInstance
 }
 
+// RestartDMAContext starts a transfer from page start. Restarting during a
+// transfer begins again from the first byte; the old transfer keeps the bus
+// during the setup cycle.
 func (d *DMAContext) RestartDMAContext(start byte) {
 	if d == nil {
-		dmaInstance = NewDMAContext(start)
-		return
+		d = DmaCtx()
 	}
 
 	d.active = true
 	d.currentByte = 0
 	d.value = start
-	d.startDelay = 2
+	d.startDelay = 1
+}
+
+// DMARegister returns FF46, the last page written to it
+func (d *DMAContext) DMARegister() byte {
+	return d.value
+}
+
+// DMASource returns the address the transfer reads in the current M-cycle
+func (d *DMAContext) DMASource() uint16 {
+	return uint16(d.value)<<8 | uint16(d.currentByte)
+}
+
+// DMAData returns the byte copied in the current M-cycle, which a CPU read
+// that conflicts with the transfer sees
+func (d *DMAContext) DMAData() byte {
+	return d.data
 }
 
 func (d *DMAContext) DMATick() {
@@ -56,41 +86,43 @@ func (d *DMAContext) DMATick() {
 		d.startDelay--
 		return
 	}
+	if d.currentByte >= 0xA0 {
+		d.active = false
+		d.running = false
+		logger.Debug("DMA transfer complete! Transferred 160 bytes to OAM")
+		return
+	}
 
-	sourceAddr := (uint16(d.value) << 8) | uint16(d.currentByte)
+	d.running = true
+	sourceAddr := d.DMASource()
 	destAddr := 0xFE00 + uint16(d.currentByte)
 
-	data := memory.BusCtx().BusRead(sourceAddr)
-	memory.BusCtx().DmaWriteToOam(destAddr, data)
+	d.data = memory.BusCtx().DmaRead(sourceAddr)
+	memory.BusCtx().DmaWriteToOam(destAddr, d.data)
 
-	logger.Debug("DMA transfer: byte %d from %04X -> %04X data=%02X", d.currentByte, sourceAddr, destAddr, data)
+	logger.Debug("DMA transfer: byte %d from %04X -> %04X data=%02X", d.currentByte, sourceAddr, destAddr, d.data)
 
 	d.currentByte++
-
-	if d.currentByte >= 0xA0 {
-		d.active = false
-		logger.Debug("DMA transfer complete! Transferred 160 bytes to OAM")
-	}
 }
 
-// DMATickBatch processes multiple DMA ticks at once for better performance
-func (d *DMAContext) DMATickBatch(ticks int32) {
-	for i := int32(0); i < ticks; i++ {
+// Sync runs the transfer up to now, one M-cycle per tick, and returns the
+// time it ends
+func (d *DMAContext) Sync(now int64) int64 {
+	cycles := (now - d.lastSync) / 4
+	d.lastSync = now
+	for ; cycles > 0 && d.active; cycles-- {
 		d.DMATick()
-		// Early exit if DMA completes
-		if !d.active && d.startDelay == 0 {
-			return
-		}
 	}
-}
 
-func (d *DMAContext) DMATransferring() bool {
-	return d.active
+	if !d.active {
+		return Never
+	}
+	// Setup, the remaining bytes and the cycle that ends the transfer
+	remaining := int64(d.startDelay) + 0xA0 - int64(d.currentByte) + 1
+	return now + 4*remaining
 }
 
-func DmaStart(start uint8) {
-	logger.Debug("DMA start: source address %02X00", start)
-
-	dmaCtx := DmaCtx()
-	dmaCtx.RestartDMAContext(start)
+// DMATransferring reports whether the transfer currently owns the bus
+func (d *DMAContext) DMATransferring() bool {
+	return d.running
 }
@@ -0,0 +1,78 @@
+package cpu
+
+import (
+	"app/internal/memory"
+	"testing"
+)
+
+// dmaTestMem stands in for the cartridge, PPU and IO behind a memory.Bus
+type dmaTestMem struct {
+	oam [0xA0]byte
+}
+
+func (m *dmaTestMem) CartRead(address uint16) byte         { return byte(address) }
+func (m *dmaTestMem) CartWrite(address uint16, data byte)  {}
+func (m *dmaTestMem) VramRead(address uint16) byte         { return 0 }
+func (m *dmaTestMem) VramWrite(address uint16, value byte) {}
+func (m *dmaTestMem) OamRead(address uint16) byte          { return m.oam[address-0xFE00] }
+func (m *dmaTestMem) OamWrite(address uint16, value byte)  { m.oam[address-0xFE00] = value }
+func (m *dmaTestMem) Read(address uint16) byte             { return 0 }
+func (m *dmaTestMem) Write(address uint16, value byte)     {}
+func (m *dmaTestMem) VramBlocked() bool                    { return false }
+func (m *dmaTestMem) OamBlocked() bool                     { return false }
+
+func newTestDma() (*DMAContext, *dmaTestMem) {
+	Cm.Reset()
+	mem := &dmaTestMem{}
+	dma := ResetDmaCtx()
+	memory.NewBus(mem, memory.NewRamContext(), dma, mem, mem, nil)
+	return dma, mem
+}
+
+func TestDmaTiming(t *testing.T) {
+	dma, mem := newTestDma()
+	if dma.DMARegister() != 0xFF || dma.DMATransferring() {
+		t.Fatalf("after reset FF46=%02X running=%v, want FF and idle", dma.DMARegister(), dma.DMATransferring())
+	}
+
+	dma.RestartDMAContext(0x12)
+	if dma.DMARegister() != 0x12 {
+		t.Errorf("FF46 reads %02X, want 12", dma.DMARegister())
+	}
+	end := dma.Sync(0)
+	if end != 162*4 {
+		t.Errorf("transfer ends at dot %d, want %d", end, 162*4)
+	}
+
+	dma.Sync(4) // Setup
+	if dma.DMATransferring() {
+		t.Error("transferring during the setup M-cycle")
+	}
+	dma.Sync(8)
+	if !dma.DMATransferring() || mem.oam[0] != 0x00 || dma.DMAData() != 0x00 {
+		t.Errorf("first byte: running=%v data=%02X", dma.DMATransferring(), dma.DMAData())
+	}
+	dma.Sync(161 * 4)
+	if !dma.DMATransferring() || mem.oam[0x9F] != 0x9F {
+		t.Errorf("last byte: running=%v OAM[9F]=%02X", dma.DMATransferring(), mem.oam[0x9F])
+	}
+	if next := dma.Sync(162 * 4); dma.DMATransferring() || next != Never {
+		t.Errorf("after the transfer: running=%v next=%d", dma.DMATransferring(), next)
+	}
+}
+
+func TestDmaRestart(t *testing.T) {
+	dma, mem := newTestDma()
+	dma.RestartDMAContext(0x12)
+	dma.Sync(51 * 4) // 50 bytes copied
+
+	dma.RestartDMAContext(0xC0)
+	dma.Sync(52 * 4)
+	if !dma.DMATransferring() {
+		t.Error("bus released during the restart setup")
+	}
+	dma.Sync(53 * 4)
+	if dma.DMASource() != 0xC001 || mem.oam[0] != 0x00 {
+		t.Errorf("after restart: source %04X OAM[0]=%02X, want C001 and WRAM's 00", dma.DMASource(), mem.oam[0])
+	}
+}
@@ -14,50 +14,64 @@ const (
 	IT_JOYPAD                             // 0x10
 )
 
-func IntHandle(ctx *CpuContext, address uint16, it InterruptType) {
-	// Disable interrupts (IME = 0)
-	ctx.IntMasterEnabled = false
-	logger.Debug("Handling interrupt %v at PC=%04X -> jumping to %04X", it, ctx.Regs.Pc, address)
-
-	// Clear the corresponding interrupt flag
-	ctx.IntFlags &= ^byte(it)
-
-	// Push current PC to stack
-	StackPush16(ctx.Regs.Pc)
+// interruptVectors are the handler addresses in priority order, highest first
+var interruptVectors = [...]struct {
+	it      InterruptType
+	address uint16
+}{
+	{IT_VBLANK, 0x40},
+	{IT_LCD_STAT, 0x48},
+	{IT_TIMER, 0x50},
+	{IT_SERIAL, 0x58},
+	{IT_JOYPAD, 0x60},
+}
 
-	// Jump to interrupt vector
-	ctx.Regs.Pc = address
+// pendingInterrupts returns the interrupts that are both requested and
+// enabled. IE is read from the bus, not the cached copy.
+func (c *CpuContext) pendingInterrupts() byte {
+	return c.memoryBus.BusRead(0xFFFF) & c.IntFlags & 0x1F
 }
 
-func IntCheck(ctx *CpuContext, address uint16, it InterruptType) bool {
-	if !ctx.IntMasterEnabled {
-		return false
+// CpuHandleInterrupts dispatches the highest priority pending interrupt,
+// taking 5 M-cycles: two internal, two pushes and the jump. A halted CPU
+// needs one more M-cycle to wake up.
+//
+// The vector is chosen after the high byte of PC is pushed. If that push
+// overwrote IE and cleared every pending interrupt, the dispatch is cancelled:
+// PC is set to 0x0000 and no IF bit is cleared.
+func CpuHandleInterrupts(ctx *CpuContext) {
+	if ctx.pendingInterrupts() == 0 {
+		return
 	}
 
-	ieRegister := ctx.memoryBus.BusRead(0xFFFF) // Read IE from bus, not cached copy
-
-	ifFlag := (ctx.IntFlags & byte(it)) != 0
-	ieFlag := (ieRegister & byte(it)) != 0
-
-	if ifFlag && ieFlag {
-		IntHandle(ctx, address, it)
+	ctx.IntMasterEnabled = false
+	if ctx.Halted {
 		ctx.Halted = false
-		Cm.IncreaseCycle(2) // Interrupt handling takes additional cycles
-		return true
+		internalCycle()
 	}
-	return false
-}
 
-func CpuHandleInterrupts(ctx *CpuContext) {
-	if IntCheck(ctx, 0x40, IT_VBLANK) {
-		return
-	} else if IntCheck(ctx, 0x48, IT_LCD_STAT) {
-		return
-	} else if IntCheck(ctx, 0x50, IT_TIMER) {
-		return
-	} else if IntCheck(ctx, 0x58, IT_SERIAL) {
-		return
-	} else if IntCheck(ctx, 0x60, IT_JOYPAD) {
-		return
+	pc := ctx.Regs.Pc
+	if ctx.haltBug {
+		// EI; HALT with an interrupt pending: the handler returns to the
+		// HALT, which runs again
+		pc--
+		ctx.haltBug = false
+	}
+	internalCycle()
+	iduCycle(ctx.Regs.Sp)
+	StackPush(byte(pc >> 8))
+	pending := ctx.pendingInterrupts()
+	StackPush(byte(pc))
+
+	ctx.Regs.Pc = 0x0000
+	for _, v := range interruptVectors {
+		if pending&byte(v.it) != 0 {
+			ctx.IntFlags &^= byte(v.it)
+			ctx.Regs.Pc = v.address
+			break
+		}
 	}
+	internalCycle()
+
+	logger.Debug("Handling interrupt %02X at PC=%04X -> jumping to %04X", pending, pc, ctx.Regs.Pc)
 }
@@ -0,0 +1,225 @@
+package cpu
+
+import "testing"
+
+func TestHaltWakesOnEnabledInterruptOnly(t *testing.T) {
+	c, bus := newTestCpu(0x76, 0x3C) // halt; inc a
+	c.RequestInterrupt(IT_TIMER)     // Requested but not enabled
+	c.Regs.A = 0
+
+	for i := 0; i < 10; i++ {
+		c.Step()
+	}
+	if !c.Halted {
+		t.Fatal("HALT ended with IE & IF = 0")
+	}
+
+	bus.mem[0xFFFF] = byte(IT_TIMER)
+	c.Step() // Wakes without servicing, IME is clear
+	if c.Halted {
+		t.Fatal("HALT did not end with IE & IF != 0")
+	}
+	c.Step()
+	if c.Regs.A != 1 || c.Regs.Pc != 0x102 {
+		t.Errorf("after wake A=%d PC=%04X, want A=1 PC=0102", c.Regs.A, c.Regs.Pc)
+	}
+}
+
+func TestHaltBug(t *testing.T) {
+	// With IME clear and an interrupt pending, HALT doesn't halt and the
+	// byte after it is read twice: inc a runs two times
+	c, bus := newTestCpu(0x76, 0x3C, 0x00) // halt; inc a; nop
+	bus.mem[0xFFFF] = byte(IT_VBLANK)
+	c.RequestInterrupt(IT_VBLANK)
+	c.Regs.A = 0
+
+	for i := 0; i < 3; i++ {
+		c.Step()
+		if c.Halted {
+			t.Fatalf("step %d: CPU halted", i)
+		}
+	}
+	if c.Regs.A != 2 || c.Regs.Pc != 0x102 {
+		t.Errorf("A=%d PC=%04X, want A=2 PC=0102", c.Regs.A, c.Regs.Pc)
+	}
+}
+
+func TestHaltWakeDispatchTiming(t *testing.T) {
+	c, bus := newTestCpu(0x76) // halt
+	bus.mem[0xFFFF] = byte(IT_TIMER)
+	c.IntMasterEnabled = true
+
+	c.Step()
+	c.Step()
+	if !c.Halted {
+		t.Fatal("CPU not halted")
+	}
+
+	// One halted M-cycle, one to wake up and five to dispatch
+	c.RequestInterrupt(IT_TIMER)
+	if got := stepCycles(c); got != 7 {
+		t.Errorf("wake and dispatch took %d M-cycles, want 7", got)
+	}
+	if c.Regs.Pc != 0x50 || c.Halted {
+		t.Errorf("PC=%04X halted=%v, want PC=0050 running", c.Regs.Pc, c.Halted)
+	}
+	if ret := uint16(bus.mem[c.Regs.Sp]) | uint16(bus.mem[c.Regs.Sp+1])<<8; ret != 0x101 {
+		t.Errorf("pushed return address %04X, want 0101", ret)
+	}
+}
+
+func TestIePushDispatch(t *testing.T) {
+	// With SP at 0x0000 the high byte of PC is pushed into IE, which picks
+	// the vector
+	tests := []struct {
+		name    string
+		pc      uint16
+		ifFlags byte
+		wantPc  uint16
+		wantIf  byte
+	}{
+		{"IE keeps V-blank", 0x0101, 0x01, 0x0040, 0x00},
+		{"IE cleared cancels", 0x0201, 0x01, 0x0000, 0x01},
+		{"IE switches to STAT", 0x0201, 0x03, 0x0048, 0x01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, bus := newTestCpu()
+			bus.mem[tt.pc-1] = 0x00 // nop
+			c.Regs.Pc = tt.pc - 1
+			c.Regs.Sp = 0x0000
+			bus.mem[0xFFFF] = byte(IT_VBLANK)
+			c.IntFlags = tt.ifFlags
+			c.IntMasterEnabled = true
+
+			if got := stepCycles(c); got != 6 {
+				t.Errorf("NOP + dispatch took %d M-cycles, want 6", got)
+			}
+			if c.Regs.Pc != tt.wantPc {
+				t.Errorf("PC = %04X, want %04X", c.Regs.Pc, tt.wantPc)
+			}
+			if c.IntFlags != tt.wantIf {
+				t.Errorf("IF = %02X, want %02X", c.IntFlags, tt.wantIf)
+			}
+			if bus.mem[0xFFFF] != byte(tt.pc>>8) || bus.mem[0xFFFE] != byte(tt.pc) {
+				t.Errorf("pushed %02X%02X, want %04X", bus.mem[0xFFFF], bus.mem[0xFFFE], tt.pc)
+			}
+			if c.IntMasterEnabled {
+				t.Error("IME still set after dispatch")
+			}
+		})
+	}
+}
+
+// pendingVBlank sets up a V-blank interrupt that is requested and enabled
+func pendingVBlank(c *CpuContext, bus *testBus) {
+	bus.mem[0xFFFF] = byte(IT_VBLANK)
+	c.RequestInterrupt(IT_VBLANK)
+}
+
+func returnAddress(c *CpuContext, bus *testBus) uint16 {
+	return uint16(bus.mem[c.Regs.Sp]) | uint16(bus.mem[c.Regs.Sp+1])<<8
+}
+
+func TestEiDelaysOneInstruction(t *testing.T) {
+	c, bus := newTestCpu(0xFB, 0x00, 0x00) // ei; nop; nop
+	pendingVBlank(c, bus)
+
+	c.Step()
+	if c.IntMasterEnabled || c.Regs.Pc != 0x101 {
+		t.Fatalf("after EI: IME=%v PC=%04X, want IME off at 0101", c.IntMasterEnabled, c.Regs.Pc)
+	}
+	c.Step()
+	if c.Regs.Pc != 0x40 {
+		t.Fatalf("after the instruction following EI: PC=%04X, want 0040", c.Regs.Pc)
+	}
+	if ret := returnAddress(c, bus); ret != 0x102 {
+		t.Errorf("return address %04X, want 0102", ret)
+	}
+}
+
+func TestEiDiServicesNothing(t *testing.T) {
+	c, bus := newTestCpu(0xFB, 0xF3, 0x00, 0x00) // ei; di; nop; nop
+	pendingVBlank(c, bus)
+
+	for i := 0; i < 4; i++ {
+		c.Step()
+	}
+	if c.Regs.Pc != 0x104 || c.IntMasterEnabled {
+		t.Errorf("PC=%04X IME=%v, want 0104 with IME off", c.Regs.Pc, c.IntMasterEnabled)
+	}
+	if c.IntFlags != byte(IT_VBLANK) {
+		t.Errorf("IF = %02X, want the V-blank request untouched", c.IntFlags)
+	}
+}
+
+func TestEiHaltReturnsToHalt(t *testing.T) {
+	// IME is still clear while HALT runs, so the HALT bug applies; the
+	// interrupt is then dispatched with the HALT as return address
+	c, bus := newTestCpu(0xFB, 0x76, 0x00) // ei; halt; nop
+	pendingVBlank(c, bus)
+
+	c.Step()
+	c.Step()
+	if c.Regs.Pc != 0x40 {
+		t.Fatalf("PC=%04X, want 0040", c.Regs.Pc)
+	}
+	if ret := returnAddress(c, bus); ret != 0x101 {
+		t.Errorf("return address %04X, want the HALT at 0101", ret)
+	}
+}
+
+func TestRetiEnablesImmediately(t *testing.T) {
+	c, bus := newTestCpu(0xD9) // reti
+	c.Regs.Sp = 0xDFEE
+	bus.mem[0xDFEE], bus.mem[0xDFEF] = 0x00, 0x02
+	pendingVBlank(c, bus)
+
+	// Returns to 0200 and is interrupted before running anything there
+	c.Step()
+	if c.Regs.Pc != 0x40 {
+		t.Fatalf("PC=%04X, want 0040", c.Regs.Pc)
+	}
+	if ret := returnAddress(c, bus); ret != 0x200 {
+		t.Errorf("return address %04X, want 0200", ret)
+	}
+}
+
+func TestDiDisablesImmediately(t *testing.T) {
+	c, bus := newTestCpu(0xF3, 0x00) // di; nop
+	c.IntMasterEnabled = true
+	pendingVBlank(c, bus)
+
+	c.Step()
+	if c.IntMasterEnabled || c.Regs.Pc != 0x101 {
+		t.Errorf("after DI: IME=%v PC=%04X, want IME off at 0101", c.IntMasterEnabled, c.Regs.Pc)
+	}
+}
+
+func TestCpuHandleInterruptsPriority(t *testing.T) {
+	c, bus := newTestCpu()
+	bus.mem[0xFFFF] = byte(IT_TIMER | IT_SERIAL | IT_JOYPAD)
+	c.IntFlags = 0x1F
+	c.IntMasterEnabled = true
+
+	CpuHandleInterrupts(c)
+	if c.Regs.Pc != 0x50 {
+		t.Errorf("PC=%04X, want the timer vector 0050", c.Regs.Pc)
+	}
+	if c.IntFlags != 0x1F&^byte(IT_TIMER) {
+		t.Errorf("IF = %02X, want only the timer bit cleared", c.IntFlags)
+	}
+	if c.IntMasterEnabled {
+		t.Error("IME still set after dispatch")
+	}
+
+	// Nothing pending: no dispatch and no cycles
+	c.IntFlags = byte(IT_VBLANK)
+	c.IntMasterEnabled = true
+	start := Cm.GetCycleTicks()
+	CpuHandleInterrupts(c)
+	if Cm.GetCycleTicks() != start || c.Regs.Pc != 0x50 || !c.IntMasterEnabled {
+		t.Error("dispatched an interrupt that is not enabled")
+	}
+}
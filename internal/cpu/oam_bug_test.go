@@ -0,0 +1,65 @@
+package cpu
+
+import (
+	"fmt"
+	"testing"
+)
+
+// oamBugLog records the OAM corruption triggers reported by the CPU
+type oamBugLog []string
+
+func (l *oamBugLog) OamBugWrite(address uint16) {
+	*l = append(*l, fmt.Sprintf("write %04X", address))
+}
+
+func (l *oamBugLog) OamBugRead(address uint16) {
+	*l = append(*l, fmt.Sprintf("read %04X", address))
+}
+
+func (l *oamBugLog) OamBugReadIncrease(address uint16) {
+	*l = append(*l, fmt.Sprintf("read+inc %04X", address))
+}
+
+func TestOamBugTriggers(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []byte
+		hl, sp  uint16
+		want    []string
+	}{
+		{"INC HL", []byte{0x23}, 0xFE10, 0xDFF0, []string{"write FE10"}},
+		{"DEC HL", []byte{0x2B}, 0xFE10, 0xDFF0, []string{"write FE10"}},
+		{"INC SP", []byte{0x33}, 0xC000, 0xFEFF, []string{"write FEFF"}},
+		{"INC HL outside OAM", []byte{0x23}, 0xFDFF, 0xDFF0, nil},
+		{"LD A,(HL+)", []byte{0x2A}, 0xFE10, 0xDFF0, []string{"read+inc FE10"}},
+		{"LD A,(HL-)", []byte{0x3A}, 0xFE10, 0xDFF0, []string{"read+inc FE10"}},
+		{"LD (HL+),A", []byte{0x22}, 0xFE10, 0xDFF0, []string{"write FE10"}},
+		{"LD A,(HL)", []byte{0x7E}, 0xFE10, 0xDFF0, []string{"read FE10"}},
+		{"PUSH BC", []byte{0xC5}, 0xC000, 0xFE20, []string{"write FE20", "write FE1F", "write FE1E"}},
+		{"POP BC", []byte{0xC1}, 0xC000, 0xFE20, []string{"read+inc FE20", "read FE21"}},
+		{"RST 00", []byte{0xC7}, 0xC000, 0xFE20, []string{"write FE20", "write FE1F", "write FE1E"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestCpu(tt.program...)
+			log := &oamBugLog{}
+			c.SetOamBug(log)
+			c.Regs.H, c.Regs.L = byte(tt.hl>>8), byte(tt.hl)
+			c.Regs.Sp = tt.sp
+			c.Step()
+			if fmt.Sprint(*log) != fmt.Sprint(tt.want) {
+				t.Errorf("triggers %v, want %v", *log, tt.want)
+			}
+		})
+	}
+}
+
+func TestOamBugDisabled(t *testing.T) {
+	c, _ := newTestCpu(0x23) // INC HL
+	c.Regs.H, c.Regs.L = 0xFE, 0x10
+	c.Step() // Must not call a nil OamBug
+	if c.ReadRegHL() != 0xFE11 {
+		t.Errorf("HL = %04X, want FE11", c.ReadRegHL())
+	}
+}
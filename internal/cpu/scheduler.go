@@ -0,0 +1,57 @@
+package cpu
+
+import "math"
+
+// Never is the event time of a component with nothing scheduled
+const Never = math.MaxInt64
+
+// EventKind identifies a scheduled event. Each kind belongs to one
+// component, and at most one event of each kind is pending.
+type EventKind int
+
+const (
+	EventPpu   EventKind = iota // Next PPU mode change that may raise an interrupt
+	EventTimer                  // Next TIMA overflow
+	EventDma                    // End of the OAM DMA transfer
+	numEvents
+)
+
+// Component is a device that runs lazily. Sync catches it up to now, in dots
+// since power-on, and returns the time of its next event: the earliest point
+// at which it may raise an interrupt, or Never.
+type Component interface {
+	Sync(now int64) int64
+}
+
+// Scheduler holds the time of the next event of each kind
+type Scheduler struct {
+	at   [numEvents]int64
+	next int64 // Earliest of at
+}
+
+// Schedule sets the time of the event of the given kind, replacing any
+// pending one
+func (s *Scheduler) Schedule(kind EventKind, at int64) {
+	s.at[kind] = at
+	s.next = s.at[0]
+	for _, t := range s.at[1:] {
+		s.next = min(s.next, t)
+	}
+}
+
+// Due reports whether the event of the given kind has been reached
+func (s *Scheduler) Due(kind EventKind, now int64) bool {
+	return s.at[kind] <= now
+}
+
+// Next returns the time of the earliest pending event
+func (s *Scheduler) Next() int64 {
+	return s.next
+}
+
+// reset schedules every event at time 0, so all components sync on the
+// first cycle
+func (s *Scheduler) reset() {
+	s.at = [numEvents]int64{}
+	s.next = 0
+}
@@ -0,0 +1,62 @@
+package cpu
+
+import "testing"
+
+func TestTimerSyncMatchesTicking(t *testing.T) {
+	newTestCpu()
+	for _, tac := range []byte{0x04, 0x05, 0x06, 0x07} {
+		ticked := &TimerContext{div: 0xABC4, tima: 0xFD, tma: 0xF0, tac: tac}
+		synced := *ticked
+		next := synced.Sync(0)
+
+		// Sync in uneven steps, as bus accesses would
+		now := int64(0)
+		reload := int64(-1)
+		for _, step := range []int64{4, 12, 100, 4, 2000, 36, 5000, 8} {
+			for i := int64(0); i < step; i++ {
+				ticked.Tick()
+				if ticked.justReloaded() && reload < 0 {
+					reload = ticked.lastSync
+				}
+			}
+			now += step
+			synced.Sync(now)
+			if synced.div != ticked.div || synced.tima != ticked.tima {
+				t.Fatalf("TAC %02X at %d: synced DIV=%04X TIMA=%02X, ticked DIV=%04X TIMA=%02X",
+					tac, now, synced.div, synced.tima, ticked.div, ticked.tima)
+			}
+		}
+		if next != reload {
+			t.Errorf("TAC %02X: first interrupt predicted at %d, happened at %d", tac, next, reload)
+		}
+	}
+}
+
+func TestHaltSkipsToNextEvent(t *testing.T) {
+	// With the timer as the only source, a halted CPU must wake on the same
+	// M-cycle whether it steps one cycle at a time or jumps to the event
+	wake := func(deadline int64) (int64, int) {
+		c, bus := newTestCpu(0x76) // halt
+		bus.mem[0xFFFF] = byte(IT_TIMER)
+		TimerCtx().Write(0xFF07, 0x05)
+		TimerCtx().Write(0xFF05, 0xF0)
+		Cm.SetDeadline(deadline)
+
+		steps := 0
+		c.Step()
+		for c.Halted {
+			c.Step()
+			steps++
+		}
+		return Cm.GetCycleTicks(), steps
+	}
+
+	ticks, steps := wake(0)
+	skipped, skippedSteps := wake(1 << 20)
+	if skipped != ticks {
+		t.Errorf("woke at M-cycle %d when skipping, %d when stepping", skipped, ticks)
+	}
+	if skippedSteps >= steps {
+		t.Errorf("skipping took %d halted steps, stepping took %d", skippedSteps, steps)
+	}
+}
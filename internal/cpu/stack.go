@@ -2,15 +2,14 @@ package cpu
 
 import (
 	logger "app/internal/logger"
-	"app/internal/memory"
 )
 
-// StackPush: Pushes a byte onto the stack (decrement SP, then write)
+// StackPush: Pushes a byte onto the stack (decrement SP, then write). Takes one M-cycle.
 func StackPush(data byte) {
 	regs := CpuGetRegs()
 	regs.Sp--
 	addr := regs.Sp
-	memory.BusCtx().BusWrite(addr, data)
+	busWrite(addr, data)
 	logger.Debug("StackPush: wrote %02X to %04X (SP now %04X)", data, addr, regs.Sp)
 }
 
@@ -24,17 +23,20 @@ func StackPush16(data uint16) {
 	StackPush(lowByte)
 }
 
-// StackPop: Pops a byte from the stack (read, then increment SP)
+// StackPop: Pops a byte from the stack (read, then increment SP). Takes one M-cycle.
 func StackPop() byte {
 	regs := CpuGetRegs()
-	result := memory.BusCtx().BusRead(regs.Sp)
+	result := busRead(regs.Sp)
 	regs.Sp++
 	return result
 }
 
-// StackPop16: Pops a 16-bit value from the stack (low byte first, then high byte)
+// StackPop16: Pops a 16-bit value from the stack (low byte first, then high byte).
+// The IDU increments SP during the first read.
 func StackPop16() uint16 {
-	low := uint16(StackPop())
+	regs := CpuGetRegs()
+	low := uint16(busReadIncrease(regs.Sp))
+	regs.Sp++
 	high := uint16(StackPop())
 	return (high << 8) | low
 }
@@ -10,90 +10,192 @@ type Timer interface {
 	Read(address uint16) byte
 }
 
+// timaState is where TIMA is in its overflow sequence
+type timaState byte
+
+const (
+	timaCounting   timaState = iota
+	timaOverflowed           // TIMA reads 0 for one M-cycle; writing TIMA cancels the reload
+	timaReloaded             // TMA was just loaded; TIMA writes are ignored, TMA writes reach TIMA
+)
+
+// TimerContext is the DIV/TIMA timer. DIV is the upper byte of a 16-bit
+// system counter, and TIMA counts falling edges of one counter bit ANDed
+// with the TAC enable bit. Anything that drops that signal, including DIV
+// and TAC writes, increments TIMA.
 type TimerContext struct {
 	div  uint16
 	tima byte
 	tma  byte
 	tac  byte
+
+	state   timaState
+	stateAt int64 // Time of the overflow or reload that set state
+
+	lastSync int64 // Time the timer was last brought up to date, in dots
 }
 
+// timerBits is the DIV bit whose falling edge increments TIMA, by TAC clock select
+var timerBits = [4]uint{9, 3, 5, 7}
+
 var timerInstance *TimerContext
 
+// NewTimerContext creates a timer in its post-boot state and makes it the singleton
+func NewTimerContext() *TimerContext {
+	timerInstance = &TimerContext{
+		div:      0xAC00,
+		lastSync: Cm.now,
+	}
+	return timerInstance
+}
+
 func TimerCtx() *TimerContext {
 	if timerInstance == nil {
-		timerInstance = &TimerContext{
-			div: 0xAC00,
-		}
+		NewTimerContext()
 	}
 	return timerInstance
 }
 
+func (t *TimerContext) enabled() bool {
+	return t.tac&(1<<2) != 0
+}
+
+// signal is the input of the falling-edge detector
+func (t *TimerContext) signal() bool {
+	return t.enabled() && t.div&(1<<timerBits[t.tac&0x03]) != 0
+}
+
+// period is the number of dots between falling edges of the selected bit
+func (t *TimerContext) period() int64 {
+	return int64(1) << (timerBits[t.tac&0x03] + 1)
+}
+
+// Tick advances the timer by one dot
 func (t *TimerContext) Tick() {
-	prevDiv := t.div
+	t.lastSync++
+	t.reloadIfDue()
+	old := t.signal()
 	t.div++
+	if old && !t.signal() {
+		t.incrementTima()
+	}
+}
+
+// TickBatch advances the timer by ticks dots
+func (t *TimerContext) TickBatch(ticks int32) {
+	t.advance(t.lastSync + int64(ticks))
+}
 
-	if t.tac&(1<<2) != 0 { // Timer enabled
-		var timerUpdate bool
-		switch t.tac & 0x03 {
-		case 0x00:
-			// 4096 Hz (Bit 9)
-			timerUpdate = (prevDiv&(1<<9) != 0) && (t.div&(1<<9) == 0)
-		case 0x01:
-			// 262144 Hz (Bit 3)
-			timerUpdate = (prevDiv&(1<<3) != 0) && (t.div&(1<<3) == 0)
-		case 0x02:
-			// 65536 Hz (Bit 5)
-			timerUpdate = (prevDiv&(1<<5) != 0) && (t.div&(1<<5) == 0)
-		case 0x03:
-			// 16384 Hz (Bit 7)
-			timerUpdate = (prevDiv&(1<<7) != 0) && (t.div&(1<<7) == 0)
+// advance runs the timer up to now. Runs of falling edges are applied at
+// once; it only stops at an overflow and at the reload one M-cycle later.
+func (t *TimerContext) advance(now int64) {
+	for t.lastSync < now {
+		dots := now - t.lastSync
+		if t.state == timaOverflowed {
+			dots = min(dots, t.stateAt+4-t.lastSync)
 		}
 
-		if timerUpdate {
-			prevTima := t.tima
-			t.tima++
-			if t.tima == 0 {
-				t.tima = t.tma
-				logger.Debug("Timer overflow: prev=%02X reload=%02X div=%04X tac=%02X", prevTima, t.tma, t.div, t.tac)
-				CpuCtx().RequestInterrupt(IT_TIMER)
+		if t.enabled() {
+			// The selected bit falls each time DIV crosses a multiple of the period
+			period := t.period()
+			toEdge := period - int64(t.div)&(period-1)
+			if left := int64(256 - int(t.tima)); t.state != timaOverflowed && dots >= toEdge+(left-1)*period {
+				// Stop on the edge that overflows TIMA
+				dots = toEdge + (left-1)*period
 			}
+			start := uint64(t.div)
+			edges := (start+uint64(dots))>>(timerBits[t.tac&0x03]+1) - start>>(timerBits[t.tac&0x03]+1)
+			t.div += uint16(dots)
+			t.lastSync += dots
+			if edges > 0 {
+				t.tima += byte(edges - 1)
+				t.incrementTima()
+			}
+		} else {
+			t.div += uint16(dots)
+			t.lastSync += dots
 		}
+		t.reloadIfDue()
 	}
 }
 
-// TickBatch processes multiple timer ticks at once for better performance
-func (t *TimerContext) TickBatch(ticks int32) {
-	if ticks <= 0 {
-		return
+// incrementTima counts one falling edge. On overflow TIMA reads 0 until the
+// reload one M-cycle later.
+func (t *TimerContext) incrementTima() {
+	t.tima++
+	if t.tima == 0 {
+		t.state = timaOverflowed
+		t.stateAt = t.lastSync
 	}
+}
 
-	// Fast path: if timer is disabled, just update div
-	if t.tac&(1<<2) == 0 {
-		t.div += uint16(ticks)
-		return
+// reloadIfDue loads TMA and requests the timer interrupt one M-cycle after
+// an overflow
+func (t *TimerContext) reloadIfDue() {
+	if t.state == timaOverflowed && t.lastSync == t.stateAt+4 {
+		logger.Debug("Timer overflow: reload=%02X div=%04X tac=%02X", t.tma, t.div, t.tac)
+		t.tima = t.tma
+		t.state = timaReloaded
+		t.stateAt = t.lastSync
+		CpuCtx().RequestInterrupt(IT_TIMER)
 	}
+}
 
-	// Timer is enabled - need to check for overflows
-	// We still need to process each tick to detect falling edges correctly
-	for i := int32(0); i < ticks; i++ {
-		t.Tick()
+// justReloaded reports whether this is the M-cycle in which TMA was loaded
+func (t *TimerContext) justReloaded() bool {
+	return t.state == timaReloaded && t.stateAt == t.lastSync
+}
+
+// Sync runs the timer up to now and returns the time of the next timer
+// interrupt
+func (t *TimerContext) Sync(now int64) int64 {
+	t.advance(now)
+
+	if t.state == timaOverflowed {
+		return t.stateAt + 4
+	}
+	if !t.enabled() {
+		return Never
 	}
+	period := t.period()
+	toEdge := period - int64(t.div)&(period-1)
+	return now + toEdge + int64(255-t.tima)*period + 4
 }
 
 func (t *TimerContext) Write(address uint16, value byte) {
 	switch address {
 	case 0xFF04:
-		// DIV
+		// DIV: clearing the counter is a falling edge if the selected bit was set
+		old := t.signal()
 		t.div = 0
+		if old {
+			t.incrementTima()
+		}
 	case 0xFF05:
 		// TIMA
-		t.tima = value
+		switch {
+		case t.justReloaded():
+			// The reload wins
+		case t.state == timaOverflowed:
+			// Cancels the pending reload and interrupt
+			t.state = timaCounting
+			t.tima = value
+		default:
+			t.tima = value
+		}
 	case 0xFF06:
 		// TMA
 		t.tma = value
+		if t.justReloaded() {
+			t.tima = value
+		}
 	case 0xFF07:
-		// TAC
+		// TAC: disabling the timer or switching bits can be a falling edge
+		old := t.signal()
 		t.tac = value & 0x07 // Only the lower 3 bits are used
+		if old && !t.signal() {
+			t.incrementTima()
+		}
 	}
 }
 
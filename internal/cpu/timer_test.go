@@ -0,0 +1,94 @@
+package cpu
+
+import "testing"
+
+// newTestTimer returns a timer at 262144 Hz (DIV bit 3) with a CPU to take
+// its interrupts
+func newTestTimer(div uint16, tima byte) *TimerContext {
+	newTestCpu()
+	return &TimerContext{div: div, tima: tima, tma: 0x80, tac: 0x05}
+}
+
+func timerInterrupt() bool {
+	return CpuGetIntFlags()&byte(IT_TIMER) != 0
+}
+
+func TestTimerDivWriteEdge(t *testing.T) {
+	tm := newTestTimer(0x0008, 0x10)
+	tm.Write(0xFF04, 0)
+	if tm.tima != 0x11 || tm.div != 0 {
+		t.Errorf("DIV write with bit 3 set: TIMA=%02X DIV=%04X, want 11 and 0000", tm.tima, tm.div)
+	}
+
+	tm = newTestTimer(0x0004, 0x10)
+	tm.Write(0xFF04, 0)
+	if tm.tima != 0x10 {
+		t.Errorf("DIV write with bit 3 clear: TIMA=%02X, want 10", tm.tima)
+	}
+}
+
+func TestTimerTacWriteEdge(t *testing.T) {
+	tests := []struct {
+		name string
+		div  uint16
+		tac  byte
+		want byte
+	}{
+		{"disable with bit set", 0x0008, 0x01, 0x11},
+		{"disable with bit clear", 0x0004, 0x01, 0x10},
+		{"switch to a clear bit", 0x0008, 0x06, 0x11},
+		{"switch to a set bit", 0x0028, 0x06, 0x10},
+		{"same bit", 0x0008, 0x05, 0x10},
+	}
+	for _, tt := range tests {
+		tm := newTestTimer(tt.div, 0x10)
+		tm.Write(0xFF07, tt.tac)
+		if tm.tima != tt.want {
+			t.Errorf("%s: TIMA=%02X, want %02X", tt.name, tm.tima, tt.want)
+		}
+	}
+}
+
+func TestTimaReloadDelay(t *testing.T) {
+	tm := newTestTimer(0x000C, 0xFF)
+
+	tm.TickBatch(4)
+	if tm.tima != 0 || timerInterrupt() {
+		t.Fatalf("overflow cycle: TIMA=%02X IF=%v, want 00 and no interrupt yet", tm.tima, timerInterrupt())
+	}
+	tm.TickBatch(4)
+	if tm.tima != 0x80 || !timerInterrupt() {
+		t.Errorf("reload cycle: TIMA=%02X IF=%v, want TMA and the interrupt", tm.tima, timerInterrupt())
+	}
+}
+
+func TestTimaWriteDuringOverflow(t *testing.T) {
+	tm := newTestTimer(0x000C, 0xFF)
+	tm.TickBatch(4)
+	tm.Write(0xFF05, 0x42)
+	tm.TickBatch(4)
+	if tm.tima != 0x42 || timerInterrupt() {
+		t.Errorf("TIMA=%02X IF=%v, want the written 42 and no interrupt", tm.tima, timerInterrupt())
+	}
+}
+
+func TestTimerWritesOnReloadCycle(t *testing.T) {
+	tm := newTestTimer(0x000C, 0xFF)
+	tm.TickBatch(8)
+	tm.Write(0xFF05, 0x42)
+	if tm.tima != 0x80 {
+		t.Errorf("TIMA write on reload cycle: TIMA=%02X, want TMA 80", tm.tima)
+	}
+	tm.Write(0xFF06, 0x99)
+	if tm.tima != 0x99 {
+		t.Errorf("TMA write on reload cycle: TIMA=%02X, want 99", tm.tima)
+	}
+
+	// One M-cycle later both behave normally again
+	tm.TickBatch(4)
+	tm.Write(0xFF06, 0x55)
+	tm.Write(0xFF05, 0x42)
+	if tm.tima != 0x42 || tm.tma != 0x55 {
+		t.Errorf("TIMA=%02X TMA=%02X, want 42 and 55", tm.tima, tm.tma)
+	}
+}
@@ -0,0 +1,151 @@
+package cpu
+
+import "testing"
+
+// testBus is a flat 64 KiB memory that records the dot count of the
+// attached PPU at every read of watchAddr
+type testBus struct {
+	mem       [0x10000]byte
+	ppu       *dotCounter
+	watchAddr uint16
+	watched   []int32
+}
+
+func (b *testBus) BusRead(address uint16) byte {
+	if b.ppu != nil && address == b.watchAddr {
+		b.watched = append(b.watched, b.ppu.dots)
+	}
+	return b.mem[address]
+}
+
+func (b *testBus) BusWrite(address uint16, data byte) {
+	b.mem[address] = data
+}
+
+type dotCounter struct {
+	dots int32
+}
+
+func (d *dotCounter) Sync(now int64) int64 {
+	d.dots = int32(now)
+	return Never
+}
+
+// newTestCpu builds a CPU on a fresh testBus with the program at 0x100
+func newTestCpu(program ...byte) (*CpuContext, *testBus) {
+	Cm.Reset()
+	NewTimerContext()
+	bus := &testBus{}
+	copy(bus.mem[0x100:], program)
+	c := NewCpuContext(bus)
+	Cm.Attach(nil, nil)
+	c.Regs.H, c.Regs.L = 0xC0, 0x00
+	c.Regs.Sp = 0xDFF0
+	return c, bus
+}
+
+// stepCycles executes one instruction and returns the M-cycles it took
+func stepCycles(c *CpuContext) int64 {
+	start := Cm.GetCycleTicks()
+	c.Step()
+	return Cm.GetCycleTicks() - start
+}
+
+func TestInstructionTiming(t *testing.T) {
+	const (
+		flagsNone = 0x00
+		flagsAll  = 0xF0
+	)
+	tests := []struct {
+		name    string
+		program []byte
+		flags   byte
+		cycles  int64
+	}{
+		{"NOP", []byte{0x00}, flagsNone, 1},
+		{"LD BC,d16", []byte{0x01, 0x34, 0x12}, flagsNone, 3},
+		{"LD (BC),A", []byte{0x02}, flagsNone, 2},
+		{"INC BC", []byte{0x03}, flagsNone, 2},
+		{"LD (a16),SP", []byte{0x08, 0x00, 0xC1}, flagsNone, 5},
+		{"ADD HL,BC", []byte{0x09}, flagsNone, 2},
+		{"JR e8", []byte{0x18, 0x02}, flagsNone, 3},
+		{"JR NZ taken", []byte{0x20, 0x02}, flagsNone, 3},
+		{"JR NZ not taken", []byte{0x20, 0x02}, flagsAll, 2},
+		{"INC (HL)", []byte{0x34}, flagsNone, 3},
+		{"LD (HL),d8", []byte{0x36, 0x42}, flagsNone, 3},
+		{"LD A,(HL)", []byte{0x7E}, flagsNone, 2},
+		{"ADD A,(HL)", []byte{0x86}, flagsNone, 2},
+		{"RET NZ taken", []byte{0xC0}, flagsNone, 5},
+		{"RET NZ not taken", []byte{0xC0}, flagsAll, 2},
+		{"POP BC", []byte{0xC1}, flagsNone, 3},
+		{"JP NZ taken", []byte{0xC2, 0x00, 0x02}, flagsNone, 4},
+		{"JP NZ not taken", []byte{0xC2, 0x00, 0x02}, flagsAll, 3},
+		{"JP a16", []byte{0xC3, 0x00, 0x02}, flagsNone, 4},
+		{"CALL NZ taken", []byte{0xC4, 0x00, 0x02}, flagsNone, 6},
+		{"CALL NZ not taken", []byte{0xC4, 0x00, 0x02}, flagsAll, 3},
+		{"PUSH BC", []byte{0xC5}, flagsNone, 4},
+		{"RST 00", []byte{0xC7}, flagsNone, 4},
+		{"RET", []byte{0xC9}, flagsNone, 4},
+		{"CALL a16", []byte{0xCD, 0x00, 0x02}, flagsNone, 6},
+		{"RLC B", []byte{0xCB, 0x00}, flagsNone, 2},
+		{"RLC (HL)", []byte{0xCB, 0x06}, flagsNone, 4},
+		{"BIT 0,(HL)", []byte{0xCB, 0x46}, flagsNone, 3},
+		{"SET 0,(HL)", []byte{0xCB, 0xC6}, flagsNone, 4},
+		{"RETI", []byte{0xD9}, flagsNone, 4},
+		{"LDH (a8),A", []byte{0xE0, 0x80}, flagsNone, 3},
+		{"LD (C),A", []byte{0xE2}, flagsNone, 2},
+		{"ADD SP,e8", []byte{0xE8, 0x02}, flagsNone, 4},
+		{"JP HL", []byte{0xE9}, flagsNone, 1},
+		{"LD (a16),A", []byte{0xEA, 0x00, 0xC1}, flagsNone, 4},
+		{"LDH A,(a8)", []byte{0xF0, 0x80}, flagsNone, 3},
+		{"LD HL,SP+e8", []byte{0xF8, 0x02}, flagsNone, 3},
+		{"LD SP,HL", []byte{0xF9}, flagsNone, 2},
+		{"LD A,(a16)", []byte{0xFA, 0x00, 0xC1}, flagsNone, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestCpu(tt.program...)
+			c.Regs.F = tt.flags
+			if got := stepCycles(c); got != tt.cycles {
+				t.Errorf("took %d M-cycles, want %d", got, tt.cycles)
+			}
+		})
+	}
+}
+
+func TestInterruptDispatchTiming(t *testing.T) {
+	c, bus := newTestCpu(0x00)
+	bus.mem[0xFFFF] = byte(IT_VBLANK)
+	c.IntMasterEnabled = true
+	c.RequestInterrupt(IT_VBLANK)
+
+	// NOP, then 5 M-cycles to dispatch to the V-blank vector
+	if got := stepCycles(c); got != 6 {
+		t.Errorf("NOP + dispatch took %d M-cycles, want 6", got)
+	}
+	if c.Regs.Pc != 0x40 {
+		t.Errorf("PC = %04X, want 0040", c.Regs.Pc)
+	}
+	if ret := uint16(bus.mem[c.Regs.Sp]) | uint16(bus.mem[c.Regs.Sp+1])<<8; ret != 0x101 {
+		t.Errorf("pushed return address %04X, want 0101", ret)
+	}
+}
+
+func TestComponentsAdvanceBeforeAccess(t *testing.T) {
+	// LD A,(FF44): the LY read is the fourth M-cycle, so the PPU must have
+	// been clocked through all four before the bus sees it
+	c, bus := newTestCpu(0xFA, 0x44, 0xFF)
+	bus.ppu = &dotCounter{}
+	bus.watchAddr = 0xFF44
+	Cm.Attach(bus.ppu, nil)
+	defer Cm.Reset()
+
+	c.Step()
+	if len(bus.watched) != 1 || bus.watched[0] != 16 {
+		t.Fatalf("PPU dots at LY read = %v, want [16]", bus.watched)
+	}
+	if bus.ppu.dots != 16 {
+		t.Errorf("PPU advanced %d dots, want 16", bus.ppu.dots)
+	}
+}
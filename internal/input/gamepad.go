@@ -1,5 +1,9 @@
 package input
 
+import (
+	"app/internal/cpu"
+)
+
 type State struct {
 	Start  bool
 	Select bool
@@ -11,10 +15,114 @@ type State struct {
 	Left   bool
 }
 
+// Button identifies one of the eight joypad inputs
+type Button int
+
+const (
+	ButtonA Button = iota
+	ButtonB
+	ButtonSelect
+	ButtonStart
+	ButtonRight
+	ButtonLeft
+	ButtonUp
+	ButtonDown
+	ButtonCount
+)
+
+var buttonNames = [ButtonCount]string{"a", "b", "select", "start", "right", "left", "up", "down"}
+
+func (b Button) String() string {
+	if b < 0 || b >= ButtonCount {
+		return "unknown"
+	}
+	return buttonNames[b]
+}
+
+// ParseButton looks up a button by its lower-case name ("a", "start", "up", ...)
+func ParseButton(name string) (Button, bool) {
+	for i, n := range buttonNames {
+		if n == name {
+			return Button(i), true
+		}
+	}
+	return 0, false
+}
+
+// Set updates the pressed state of a single button
+func (s *State) Set(b Button, pressed bool) {
+	switch b {
+	case ButtonA:
+		s.A = pressed
+	case ButtonB:
+		s.B = pressed
+	case ButtonSelect:
+		s.Select = pressed
+	case ButtonStart:
+		s.Start = pressed
+	case ButtonRight:
+		s.Right = pressed
+	case ButtonLeft:
+		s.Left = pressed
+	case ButtonUp:
+		s.Up = pressed
+	case ButtonDown:
+		s.Down = pressed
+	}
+}
+
+// Pressed reports whether a single button is held
+func (s *State) Pressed(b Button) bool {
+	switch b {
+	case ButtonA:
+		return s.A
+	case ButtonB:
+		return s.B
+	case ButtonSelect:
+		return s.Select
+	case ButtonStart:
+		return s.Start
+	case ButtonRight:
+		return s.Right
+	case ButtonLeft:
+		return s.Left
+	case ButtonUp:
+		return s.Up
+	case ButtonDown:
+		return s.Down
+	}
+	return false
+}
+
+// Merge ORs the pressed buttons of other into s
+func (s *State) Merge(other State) {
+	s.A = s.A || other.A
+	s.B = s.B || other.B
+	s.Select = s.Select || other.Select
+	s.Start = s.Start || other.Start
+	s.Right = s.Right || other.Right
+	s.Left = s.Left || other.Left
+	s.Up = s.Up || other.Up
+	s.Down = s.Down || other.Down
+}
+
+// CancelOpposing releases both directions of a pair that is pressed at the
+// same time. The D-pad rocker makes Left+Right and Up+Down impossible on real
+// hardware and some games misbehave when they see them.
+func (s *State) CancelOpposing() {
+	if s.Left && s.Right {
+		s.Left, s.Right = false, false
+	}
+	if s.Up && s.Down {
+		s.Up, s.Down = false, false
+	}
+}
+
 type Context struct {
 	ButtonSel  bool
 	DirSel     bool
 	Controller State
+	Host       State // Buttons held by the host page (WASM postMessage/emuInput)
 }
 
 var Ctx Context
@@ -24,6 +132,7 @@ func Init() {
 		ButtonSel:  false,
 		DirSel:     false,
 		Controller: State{},
+		Host:       State{},
 	}
 }
 
@@ -36,16 +145,45 @@ func DirSel() bool {
 }
 
 func SetSel(value uint8) {
+	prev := GetOutput()
+
 	// Joypad register uses active-low selection bits: when bit is 0 the group
 	// is selected. SetSel receives the written byte and stores booleans that
 	// are true when the corresponding group is selected.
 	Ctx.ButtonSel = (value & 0x20) == 0
 	Ctx.DirSel = (value & 0x10) == 0
+
+	// Selecting a group with a button already held also pulls a line low
+	checkJoypadInterrupt(prev)
+}
+
+// SetState replaces the controller state, requesting the joypad interrupt
+// if any selected P1 input line went from high to low
+func SetState(next State) {
+	prev := GetOutput()
+	Ctx.Controller = next
+	checkJoypadInterrupt(prev)
+}
+
+// checkJoypadInterrupt compares the P1 input lines (bits 0-3) against their
+// previous value. The joypad interrupt fires on a high-to-low transition,
+// which also wakes the CPU from STOP.
+func checkJoypadInterrupt(prev uint8) {
+	falling := prev &^ GetOutput() & 0x0F
+	if falling != 0 {
+		cpu.CpuRequestInterrupt(cpu.IT_JOYPAD)
+	}
 }
 func GetState() *State {
 	return &Ctx.Controller
 }
 
+// GetHostState returns the buttons driven by the host environment. The UI
+// merges them with keyboard and gamepad input each frame.
+func GetHostState() *State {
+	return &Ctx.Host
+}
+
 func GetOutput() uint8 {
 	var output uint8 = 0xCF
 
@@ -0,0 +1,122 @@
+package input
+
+import (
+	"testing"
+
+	"app/internal/cpu"
+)
+
+func TestCancelOpposing(t *testing.T) {
+	tests := []struct {
+		name string
+		in   State
+		want State
+	}{
+		{"none", State{}, State{}},
+		{"single direction", State{Left: true}, State{Left: true}},
+		{"diagonal", State{Left: true, Up: true}, State{Left: true, Up: true}},
+		{"left and right", State{Left: true, Right: true, Up: true}, State{Up: true}},
+		{"up and down", State{Up: true, Down: true, Right: true}, State{Right: true}},
+		{"all four", State{Left: true, Right: true, Up: true, Down: true}, State{}},
+		{"buttons kept", State{A: true, Start: true, Up: true, Down: true}, State{A: true, Start: true}},
+	}
+
+	for _, tt := range tests {
+		st := tt.in
+		st.CancelOpposing()
+		if st != tt.want {
+			t.Errorf("%s: state = %+v, want %+v", tt.name, st, tt.want)
+		}
+	}
+}
+
+// setup resets the joypad and the CPU and selects the groups given as the
+// P1 select bits
+func setup(sel uint8) *cpu.CpuContext {
+	c := cpu.NewCpuContext(nil)
+	Init()
+	SetSel(sel)
+	c.IntFlags = 0
+	return c
+}
+
+func TestJoypadInterruptOnFallingEdge(t *testing.T) {
+	tests := []struct {
+		name string
+		sel  uint8
+		prev State
+		next State
+		irq  bool
+	}{
+		{"press selected button", 0x10, State{}, State{A: true}, true},
+		{"press selected direction", 0x20, State{}, State{Down: true}, true},
+		{"press unselected button", 0x20, State{}, State{Start: true}, false},
+		{"press unselected direction", 0x10, State{}, State{Left: true}, false},
+		{"nothing selected", 0x30, State{}, State{A: true, Up: true}, false},
+		{"release", 0x10, State{A: true}, State{}, false},
+		{"hold", 0x10, State{A: true}, State{A: true}, false},
+		{"second button while held", 0x10, State{A: true}, State{A: true, B: true}, true},
+		// A and Right share P10, so the line is already low
+		{"shared line already low", 0x00, State{Right: true}, State{Right: true, A: true}, false},
+	}
+
+	for _, tt := range tests {
+		c := setup(tt.sel)
+		SetState(tt.prev)
+		c.IntFlags = 0
+
+		SetState(tt.next)
+		if got := c.IntFlags&byte(cpu.IT_JOYPAD) != 0; got != tt.irq {
+			t.Errorf("%s: joypad interrupt = %v, want %v", tt.name, got, tt.irq)
+		}
+	}
+}
+
+func TestJoypadInterruptOnSelect(t *testing.T) {
+	tests := []struct {
+		name      string
+		held      State
+		from, sel uint8
+		irq       bool
+	}{
+		{"select buttons with A held", State{A: true}, 0x30, 0x10, true},
+		{"select directions with Up held", State{Up: true}, 0x30, 0x20, true},
+		{"select directions with A held", State{A: true}, 0x30, 0x20, false},
+		{"select buttons with nothing held", State{}, 0x30, 0x10, false},
+		{"deselect with A held", State{A: true}, 0x10, 0x30, false},
+		{"switch group, line stays low", State{A: true, Right: true}, 0x10, 0x20, false},
+	}
+
+	for _, tt := range tests {
+		c := setup(tt.from)
+		SetState(tt.held)
+		c.IntFlags = 0
+
+		SetSel(tt.sel)
+		if got := c.IntFlags&byte(cpu.IT_JOYPAD) != 0; got != tt.irq {
+			t.Errorf("%s: joypad interrupt = %v, want %v", tt.name, got, tt.irq)
+		}
+	}
+}
+
+func TestJoypadWakesFromStop(t *testing.T) {
+	c := setup(0x10)
+	c.Stopped = true
+
+	// An unselected line going low doesn't wake the CPU
+	SetState(State{Up: true})
+	if !c.IsStopped() {
+		t.Fatal("woke from STOP on an unselected input")
+	}
+
+	// IE and IME don't matter
+	c.SetIERegister(0)
+	c.IntMasterEnabled = false
+	SetState(State{Up: true, Start: true})
+	if c.IsStopped() {
+		t.Error("still stopped after a joypad interrupt request")
+	}
+	if c.IntFlags&byte(cpu.IT_JOYPAD) == 0 {
+		t.Error("joypad interrupt not requested")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"app/internal/common"
 	"app/internal/cpu"
 	"app/internal/logger"
+	"app/internal/model"
 )
 
 var serialData [2]byte
@@ -19,6 +20,7 @@ type Timer interface {
 
 type DMA interface {
 	RestartDMAContext(start byte)
+	DMARegister() byte
 }
 
 type Cpu interface {
@@ -30,11 +32,64 @@ type Io struct {
 	cpu   Cpu
 	timer Timer
 	dma   DMA
+	sound [0x30]byte // FF10-FF3F; stored so they read back, but not played
+}
+
+// readMasks holds the bits of each register in FF00-FF7F that read as 1
+// regardless of its contents: unused bits, write-only registers and, with a
+// mask of 0xFF, unmapped addresses. Values are those of the DMG.
+var readMasks = [0x80]byte{
+	0x00: 0xC0, // P1
+	0x01: 0x00, // SB
+	0x02: 0x7E, // SC
+	0x03: 0xFF,
+	0x04: 0x00, // DIV
+	0x05: 0x00, // TIMA
+	0x06: 0x00, // TMA
+	0x07: 0xF8, // TAC
+	0x08: 0xFF, 0x09: 0xFF, 0x0A: 0xFF, 0x0B: 0xFF, 0x0C: 0xFF, 0x0D: 0xFF, 0x0E: 0xFF,
+	0x0F: 0xE0, // IF
+	0x10: 0x80, // NR10
+	0x11: 0x3F, // NR11
+	0x12: 0x00, // NR12
+	0x13: 0xFF, // NR13
+	0x14: 0xBF, // NR14
+	0x15: 0xFF,
+	0x16: 0x3F, // NR21
+	0x17: 0x00, // NR22
+	0x18: 0xFF, // NR23
+	0x19: 0xBF, // NR24
+	0x1A: 0x7F, // NR30
+	0x1B: 0xFF, // NR31
+	0x1C: 0x9F, // NR32
+	0x1D: 0xFF, // NR33
+	0x1E: 0xBF, // NR34
+	0x1F: 0xFF,
+	0x20: 0xFF, // NR41
+	0x21: 0x00, // NR42
+	0x22: 0x00, // NR43
+	0x23: 0xBF, // NR44
+	0x24: 0x00, // NR50
+	0x25: 0x00, // NR51
+	0x26: 0x70, // NR52
+	0x27: 0xFF, 0x28: 0xFF, 0x29: 0xFF, 0x2A: 0xFF, 0x2B: 0xFF, 0x2C: 0xFF, 0x2D: 0xFF, 0x2E: 0xFF, 0x2F: 0xFF,
+	// 0x30-0x3F: wave RAM
+	0x40: 0x00, // LCDC
+	0x41: 0x80, // STAT
+	// 0x42-0x4B: SCY, SCX, LY, LYC, DMA, BGP, OBP0, OBP1, WY, WX
+	0x4C: 0xFF, 0x4D: 0xFF, 0x4E: 0xFF, 0x4F: 0xFF,
+	0x50: 0xFF, 0x51: 0xFF, 0x52: 0xFF, 0x53: 0xFF, 0x54: 0xFF, 0x55: 0xFF, 0x56: 0xFF, 0x57: 0xFF,
+	0x58: 0xFF, 0x59: 0xFF, 0x5A: 0xFF, 0x5B: 0xFF, 0x5C: 0xFF, 0x5D: 0xFF, 0x5E: 0xFF, 0x5F: 0xFF,
+	0x60: 0xFF, 0x61: 0xFF, 0x62: 0xFF, 0x63: 0xFF, 0x64: 0xFF, 0x65: 0xFF, 0x66: 0xFF, 0x67: 0xFF,
+	0x68: 0xFF, 0x69: 0xFF, 0x6A: 0xFF, 0x6B: 0xFF, 0x6C: 0xFF, 0x6D: 0xFF, 0x6E: 0xFF, 0x6F: 0xFF,
+	0x70: 0xFF, 0x71: 0xFF, 0x72: 0xFF, 0x73: 0xFF, 0x74: 0xFF, 0x75: 0xFF, 0x76: 0xFF, 0x77: 0xFF,
+	0x78: 0xFF, 0x79: 0xFF, 0x7A: 0xFF, 0x7B: 0xFF, 0x7C: 0xFF, 0x7D: 0xFF, 0x7E: 0xFF, 0x7F: 0xFF,
 }
 
 var ioInstance *Io
 
 func NewIo(cpu Cpu, timer Timer, dma DMA) *Io {
+	serialData = [2]byte{}
 	ioInstance = &Io{
 		cpu:   cpu,
 		timer: timer,
@@ -47,10 +102,36 @@ func IoCtx() *Io {
 	return ioInstance
 }
 
+// readMask returns the bits of an IO register that always read as 1 on the
+// current model
+func readMask(address uint16) byte {
+	if address == 0xFF02 && model.Current().IsCgb() {
+		return 0x7C // SC bit 1 selects the fast serial clock
+	}
+	return readMasks[address-0xFF00]
+}
+
+// Read returns an IO register as the CPU sees it, with its unused bits set
 func (i *Io) Read(address uint16) byte {
+	mask := readMask(address)
+	if mask == 0xFF {
+		return 0xFF
+	}
+	return i.read(address) | mask
+}
+
+func (i *Io) read(address uint16) byte {
 	switch address {
 	case 0xFF00:
-		return GetOutput()
+		// Bits 4 and 5 read back the group selection
+		output := GetOutput()
+		if !ButtonSel() {
+			output |= 0x20
+		}
+		if !DirSel() {
+			output |= 0x10
+		}
+		return output
 	case 0xFF01:
 		return serialData[0]
 	case 0xFF02:
@@ -69,21 +150,22 @@ func (i *Io) Read(address uint16) byte {
 		}
 		logger.Warn("LCD not initialized for LY read at 0xFF44")
 		return 0
-	case 0xFF50:
-		// Boot ROM disable register - always return 0x01 (boot ROM disabled after simulation)
-		return 0x01
+	case 0xFF46:
+		return i.dma.DMARegister()
 	default:
 		if common.Between16(address, 0xFF04, 0xFF07) {
 			return i.timer.Read(address)
 		}
+		if common.Between16(address, 0xFF10, 0xFF3F) {
+			return i.sound[address-0xFF10]
+		}
 		if common.Between16(address, 0xFF40, 0xFF4B) {
 			if LcdReadFunc != nil {
 				return LcdReadFunc(address)
 			}
 			return 0
 		}
-		// Silently return 0 for unsupported addresses to reduce log spam
-		return 0
+		return 0xFF
 	}
 }
 
@@ -114,6 +196,8 @@ func (i *Io) Write(address uint16, value byte) {
 	default:
 		if common.Between16(address, 0xFF04, 0xFF07) {
 			i.timer.Write(address, value)
+		} else if common.Between16(address, 0xFF10, 0xFF3F) {
+			i.sound[address-0xFF10] = value
 		} else if common.Between16(address, 0xFF40, 0xFF4B) {
 			if LcdWriteFunc != nil {
 				LcdWriteFunc(address, value)
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1F, 0x8B}
+)
+
+// ErrNoRomInArchive is returned when a zip file holds no .gb/.gbc entry
+var ErrNoRomInArchive = errors.New("archive contains no .gb or .gbc file")
+
+// ExtractROM returns the ROM image inside a zip or gzip archive, detected by
+// magic bytes. Data that is not an archive is returned unchanged. For zip
+// files, entry selects a file by name; otherwise the first .gb/.gbc entry is
+// used.
+func ExtractROM(data []byte, entry string) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, zipMagic):
+		return extractZip(data, entry)
+	case bytes.HasPrefix(data, gzipMagic):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		defer zr.Close()
+		rom, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		return rom, nil
+	}
+	return data, nil
+}
+
+func extractZip(data []byte, entry string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("zip: %w", err)
+	}
+
+	var chosen *zip.File
+	for _, f := range zr.File {
+		if entry != "" {
+			if f.Name == entry || path.Base(f.Name) == entry {
+				chosen = f
+				break
+			}
+			continue
+		}
+		ext := strings.ToLower(path.Ext(f.Name))
+		if ext == ".gb" || ext == ".gbc" {
+			chosen = f
+			break
+		}
+	}
+	if chosen == nil {
+		if entry != "" {
+			return nil, fmt.Errorf("zip: no entry named %q", entry)
+		}
+		return nil, ErrNoRomInArchive
+	}
+
+	rc, err := chosen.Open()
+	if err != nil {
+		return nil, fmt.Errorf("zip: %w", err)
+	}
+	defer rc.Close()
+
+	rom, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("zip: %s: %w", chosen.Name, err)
+	}
+	return rom, nil
+}
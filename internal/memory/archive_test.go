@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+)
+
+func zipOf(t *testing.T, files map[string][]byte, order []string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range order {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(files[name])
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractROM(t *testing.T) {
+	rom := testROM()
+	other := []byte("second rom")
+	files := map[string][]byte{"readme.txt": []byte("hi"), "game/Game.GB": rom, "alt.gbc": other}
+	archive := zipOf(t, files, []string{"readme.txt", "game/Game.GB", "alt.gbc"})
+
+	if got, err := ExtractROM(archive, ""); err != nil || !bytes.Equal(got, rom) {
+		t.Errorf("zip first ROM: got %q, %v", got, err)
+	}
+	if got, err := ExtractROM(archive, "alt.gbc"); err != nil || !bytes.Equal(got, other) {
+		t.Errorf("zip named entry: got %q, %v", got, err)
+	}
+	if _, err := ExtractROM(archive, "missing.gb"); err == nil {
+		t.Errorf("zip missing entry: expected an error")
+	}
+
+	noRom := zipOf(t, map[string][]byte{"readme.txt": nil}, []string{"readme.txt"})
+	if _, err := ExtractROM(noRom, ""); !errors.Is(err, ErrNoRomInArchive) {
+		t.Errorf("zip without ROM: got %v, want ErrNoRomInArchive", err)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(rom)
+	zw.Close()
+	if got, err := ExtractROM(gz.Bytes(), ""); err != nil || !bytes.Equal(got, rom) {
+		t.Errorf("gzip: got %q, %v", got, err)
+	}
+
+	if got, err := ExtractROM(rom, ""); err != nil || !bytes.Equal(got, rom) {
+		t.Errorf("raw ROM should pass through unchanged")
+	}
+}
@@ -2,6 +2,7 @@ package memory
 
 import (
 	"app/internal/logger"
+	"app/internal/model"
 )
 
 type Ram interface {
@@ -18,6 +19,8 @@ type Cart interface {
 
 type Dma interface {
 	DMATransferring() bool
+	DMASource() uint16
+	DMAData() byte
 }
 
 type Cpu interface {
@@ -35,6 +38,8 @@ type Ppu interface {
 	OamWrite(address uint16, value byte)
 	VramWrite(address uint16, value byte)
 	VramRead(address uint16) byte
+	VramBlocked() bool
+	OamBlocked() bool
 }
 
 type Bus struct {
@@ -67,8 +72,54 @@ func BusCtx() *Bus {
 	return busInstance
 }
 
-// BusRead reads a byte from the bus at the specified address
+// onVramBus reports whether address is on the VRAM bus rather than the
+// external bus (cartridge and WRAM)
+func onVramBus(address uint16) bool {
+	return address >= 0x8000 && address < 0xA000
+}
+
+// dmaConflict reports whether a CPU access collides with a running OAM DMA,
+// which owns the bus its source is on. OAM is handled separately, and IO and
+// HRAM stay reachable.
+func (b *Bus) dmaConflict(address uint16) bool {
+	return b.dma.DMATransferring() && address < 0xFE00 && onVramBus(address) == onVramBus(b.dma.DMASource())
+}
+
+// ppuBlocked reports whether a CPU access falls in VRAM or OAM (including
+// the unusable region after it) while the PPU is using that memory
+func (b *Bus) ppuBlocked(address uint16) bool {
+	switch {
+	case onVramBus(address):
+		return b.ppu.VramBlocked()
+	case address >= 0xFE00 && address < 0xFF00:
+		return b.ppu.OamBlocked()
+	}
+	return false
+}
+
+// BusRead reads a byte from the bus at the specified address. A read that
+// conflicts with OAM DMA sees the byte being transferred, and one blocked
+// by the PPU reads 0xFF.
 func (b *Bus) BusRead(address uint16) byte {
+	if b.dmaConflict(address) {
+		return b.dma.DMAData()
+	}
+	if b.ppuBlocked(address) {
+		return 0xFF
+	}
+	return b.read(address)
+}
+
+// DmaRead reads a byte for the OAM DMA, which the PPU does not block.
+// Sources from 0xE000 up, including 0xFE00-0xFFFF, map to WRAM.
+func (b *Bus) DmaRead(address uint16) byte {
+	if address >= 0xE000 {
+		return b.ram.WramRead(address - 0x2000)
+	}
+	return b.read(address)
+}
+
+func (b *Bus) read(address uint16) byte {
 	switch {
 	case address < 0x8000:
 		// Cartridge ROM - but check for boot ROM first
@@ -88,8 +139,8 @@ func (b *Bus) BusRead(address uint16) byte {
 		// Work RAM (WRAM)
 		return b.ram.WramRead(address)
 	case address < 0xFE00:
-		// Echo RAM (not used, mirrors WRAM)
-		return 0 // Return 0 for unused memory
+		// Echo RAM, mirrors WRAM
+		return b.ram.WramRead(address - 0x2000)
 	case address < 0xFEA0:
 		// Sprite Attribute Table (OAM)
 		if b.dma.DMATransferring() {
@@ -98,8 +149,16 @@ func (b *Bus) BusRead(address uint16) byte {
 		}
 		return b.ppu.OamRead(address)
 	case address < 0xFF00:
-		// Unusable memory
-		return 0xFF
+		// Unusable memory. The DMG reads 0x00 here, or 0xFF while OAM is
+		// blocked. The CGB (revision E) and AGB repeat the high nibble of
+		// the low address byte.
+		if b.dma.DMATransferring() {
+			return 0xFF
+		}
+		if model.Current().IsCgb() {
+			return byte(address)&0xF0 | byte(address)>>4
+		}
+		return 0x00
 	case address < 0xFF80:
 		// I/O Registers
 		return b.io.Read(address)
@@ -115,8 +174,13 @@ func (b *Bus) BusRead(address uint16) byte {
 	}
 }
 
-// BusWrite writes a byte to the bus at the specified address
+// BusWrite writes a byte to the bus at the specified address. Writes that
+// conflict with OAM DMA or are blocked by the PPU are lost.
 func (b *Bus) BusWrite(address uint16, data byte) {
+	if b.dmaConflict(address) || b.ppuBlocked(address) {
+		return
+	}
+
 	switch {
 	case address < 0x8000:
 		// Cartridge ROM (writing may affect memory bank controllers)
@@ -131,8 +195,8 @@ func (b *Bus) BusWrite(address uint16, data byte) {
 		// Work RAM (WRAM)
 		b.ram.WramWrite(address, data)
 	case address < 0xFE00:
-		// Echo RAM (not used, mirrors WRAM)
-		// Writes are ignored
+		// Echo RAM, mirrors WRAM
+		b.ram.WramWrite(address-0x2000, data)
 	case address < 0xFEA0:
 		// Sprite Attribute Table (OAM)
 		if b.dma.DMATransferring() {
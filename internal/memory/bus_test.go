@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"app/internal/model"
+	"testing"
+)
+
+// testMem backs the cartridge, PPU and IO of a test bus with one flat array
+type testMem [0x10000]byte
+
+func (m *testMem) CartRead(address uint16) byte         { return m[address] }
+func (m *testMem) CartWrite(address uint16, data byte)  { m[address] = data }
+func (m *testMem) VramRead(address uint16) byte         { return m[address] }
+func (m *testMem) VramWrite(address uint16, value byte) { m[address] = value }
+func (m *testMem) OamRead(address uint16) byte          { return m[address] }
+func (m *testMem) OamWrite(address uint16, value byte)  { m[address] = value }
+func (m *testMem) Read(address uint16) byte             { return m[address] }
+func (m *testMem) Write(address uint16, value byte)     { m[address] = value }
+func (m *testMem) VramBlocked() bool                    { return m[0xFF41]&3 == 3 }
+func (m *testMem) OamBlocked() bool                     { return m[0xFF41]&3 >= 2 }
+
+type testDma struct {
+	running bool
+	source  uint16
+	data    byte
+}
+
+func (d *testDma) DMATransferring() bool { return d.running }
+func (d *testDma) DMASource() uint16     { return d.source }
+func (d *testDma) DMAData() byte         { return d.data }
+
+func newTestBus() (*Bus, *testMem, *RamContext, *testDma) {
+	mem := &testMem{}
+	ram := NewRamContext()
+	dma := &testDma{}
+	return NewBus(mem, ram, dma, mem, mem, nil), mem, ram, dma
+}
+
+func TestBusDmaConflicts(t *testing.T) {
+	bus, mem, ram, dma := newTestBus()
+	mem[0x0150], mem[0x8010], mem[0xFE00], mem[0xFF40] = 0x11, 0x22, 0x33, 0x44
+	ram.Wram[0x0100] = 0x55
+	ram.Hram[0x10] = 0x66
+
+	tests := []struct {
+		name   string
+		source uint16
+		addr   uint16
+		want   byte
+	}{
+		{"ROM from WRAM source", 0xC000, 0x0150, 0xAA},
+		{"WRAM from WRAM source", 0xC000, 0xC100, 0xAA},
+		{"VRAM from WRAM source", 0xC000, 0x8010, 0x22},
+		{"VRAM from VRAM source", 0x8000, 0x8010, 0xAA},
+		{"WRAM from VRAM source", 0x8000, 0xC100, 0x55},
+		{"OAM", 0xC000, 0xFE00, 0xFF},
+		{"IO", 0xC000, 0xFF40, 0x44},
+		{"HRAM", 0xC000, 0xFF90, 0x66},
+	}
+	for _, tt := range tests {
+		*dma = testDma{running: true, source: tt.source, data: 0xAA}
+		if got := bus.BusRead(tt.addr); got != tt.want {
+			t.Errorf("%s: read %02X, want %02X", tt.name, got, tt.want)
+		}
+	}
+
+	*dma = testDma{running: true, source: 0xC000, data: 0xAA}
+	bus.BusWrite(0xC100, 0x77)
+	bus.BusWrite(0xFF90, 0x88)
+	if ram.Wram[0x0100] != 0x55 || ram.Hram[0x10] != 0x88 {
+		t.Errorf("WRAM=%02X HRAM=%02X, want the WRAM write lost and the HRAM write kept", ram.Wram[0x0100], ram.Hram[0x10])
+	}
+
+	dma.running = false
+	if got := bus.BusRead(0x0150); got != 0x11 {
+		t.Errorf("after DMA: read %02X, want 11", got)
+	}
+}
+
+func TestDmaReadEchoSource(t *testing.T) {
+	bus, mem, ram, _ := newTestBus()
+	ram.Wram[0x0123] = 0x12
+	ram.Wram[0x1E10] = 0x34
+	mem[0x4000] = 0x56
+
+	if got := bus.DmaRead(0xE123); got != 0x12 {
+		t.Errorf("E123: %02X, want C123's 12", got)
+	}
+	if got := bus.DmaRead(0xFE10); got != 0x34 {
+		t.Errorf("FE10: %02X, want DE10's 34", got)
+	}
+	if got := bus.DmaRead(0x4000); got != 0x56 {
+		t.Errorf("4000: %02X, want 56", got)
+	}
+}
+
+func TestEchoRamAndUnusable(t *testing.T) {
+	bus, _, ram, dma := newTestBus()
+
+	bus.BusWrite(0xE123, 0x12)
+	if ram.Wram[0x0123] != 0x12 {
+		t.Errorf("write to E123 left C123 at %02X, want 12", ram.Wram[0x0123])
+	}
+	ram.Wram[0x1DFF] = 0x34
+	if got := bus.BusRead(0xFDFF); got != 0x34 {
+		t.Errorf("FDFF: %02X, want DDFF's 34", got)
+	}
+
+	bus.BusWrite(0xFEA0, 0x56)
+	if got := bus.BusRead(0xFEA0); got != 0x00 {
+		t.Errorf("FEA0: %02X, want 00", got)
+	}
+	dma.running = true
+	if got := bus.BusRead(0xFEFF); got != 0xFF {
+		t.Errorf("FEFF during DMA: %02X, want FF", got)
+	}
+}
+
+func TestBusPpuBlocking(t *testing.T) {
+	bus, mem, _, _ := newTestBus()
+	mem[0x8000], mem[0xFE00] = 0x11, 0x22
+
+	tests := []struct {
+		mode       byte
+		vram, oam  byte
+		unusable   byte
+		vramWrites bool
+		oamWrites  bool
+	}{
+		{0, 0x11, 0x22, 0x00, true, true},
+		{1, 0x11, 0x22, 0x00, true, true},
+		{2, 0x11, 0xFF, 0xFF, true, false},
+		{3, 0xFF, 0xFF, 0xFF, false, false},
+	}
+	for _, tt := range tests {
+		mem[0xFF41] = tt.mode // The fake PPU takes its mode from here
+		if got := bus.BusRead(0x8000); got != tt.vram {
+			t.Errorf("mode %d: VRAM read %02X, want %02X", tt.mode, got, tt.vram)
+		}
+		if got := bus.BusRead(0xFE00); got != tt.oam {
+			t.Errorf("mode %d: OAM read %02X, want %02X", tt.mode, got, tt.oam)
+		}
+		if got := bus.BusRead(0xFEA0); got != tt.unusable {
+			t.Errorf("mode %d: unusable read %02X, want %02X", tt.mode, got, tt.unusable)
+		}
+
+		bus.BusWrite(0x8000, 0x33)
+		bus.BusWrite(0xFE00, 0x44)
+		if (mem[0x8000] == 0x33) != tt.vramWrites || (mem[0xFE00] == 0x44) != tt.oamWrites {
+			t.Errorf("mode %d: VRAM=%02X OAM=%02X, want writes kept %v/%v", tt.mode, mem[0x8000], mem[0xFE00], tt.vramWrites, tt.oamWrites)
+		}
+		mem[0x8000], mem[0xFE00] = 0x11, 0x22
+	}
+
+	// OAM DMA isn't blocked
+	mem[0xFF41] = 3
+	bus.DmaWriteToOam(0xFE00, 0x55)
+	if mem[0xFE00] != 0x55 || bus.DmaRead(0x8000) != 0x11 {
+		t.Errorf("DMA: OAM=%02X VRAM source=%02X, want 55 and 11", mem[0xFE00], bus.DmaRead(0x8000))
+	}
+}
+
+func TestUnusableRegionCgb(t *testing.T) {
+	model.Set(model.CGB)
+	defer model.Set(model.DMG)
+	bus, _, _, _ := newTestBus()
+	if got := bus.BusRead(0xFEA5); got != 0xAA {
+		t.Errorf("FEA5: %02X, want AA", got)
+	}
+	if got := bus.BusRead(0xFEF0); got != 0xFF {
+		t.Errorf("FEF0: %02X, want FF", got)
+	}
+}
@@ -4,10 +4,16 @@ package memory
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
 	"log/slog"
 	"os"
+	"strings"
 	"unsafe"
 
+	"app/internal/cheat"
 	logger "app/internal/logger"
 )
 
@@ -15,7 +21,10 @@ import (
 type Cartridge interface {
 	CartRead(address uint16) byte
 	CartWrite(address uint16, data byte)
-	CartLoad(cart string) bool
+	CartLoad(cart string) (warnings []error, err error)
+	Title() string
+	RomCRC32() uint32
+	SetCheats(cheats *cheat.List)
 }
 
 // CartContext holds the state and data of the cartridge
@@ -29,6 +38,10 @@ type CartContext struct {
 	ramBank    int    // Current RAM bank (0-3)
 	ramEnabled bool   // RAM enable flag
 	bankMode   int    // Banking mode (0=ROM, 1=RAM)
+
+	cheats       *cheat.List // Game Genie codes patched over ROM reads
+	patchPath    string      // IPS/BPS/UPS patch to apply on load, "" to look next to the ROM
+	archiveEntry string      // File to load from a zip archive, "" for the first .gb/.gbc
 }
 
 // romHeader represents the header structure of a Game Boy ROM
@@ -87,7 +100,25 @@ var ROM_TYPES = [...][]byte{
 	[]byte("MBC7+SENSOR+RUMBLE+RAM+BATTERY"),
 }
 
-// LIC_CODE maps license codes to their string representations
+// highRomTypes names the cartridge types at the top of the range
+var highRomTypes = map[byte][]byte{
+	0xFC: []byte("POCKET CAMERA"),
+	0xFD: []byte("BANDAI TAMA5"),
+	0xFE: []byte("HuC3"),
+	0xFF: []byte("HuC1+RAM+BATTERY"),
+}
+
+// romTypeName returns the name of a cartridge type, or nil if it has none
+func romTypeName(cartType byte) []byte {
+	if int(cartType) < len(ROM_TYPES) {
+		return ROM_TYPES[cartType]
+	}
+	return highRomTypes[cartType]
+}
+
+// LIC_CODE maps the new two-character licensee codes (NewLicCode, used when
+// the old licensee byte is 0x33) to names, keyed by the code read as hex:
+// "A4" is 0xA4. Codes that aren't hex are in newLicCodeNonHex.
 var LIC_CODE = map[int][]byte{
 	0x00: []byte("None"),
 	0x01: []byte("Nintendo R&D1"),
@@ -154,46 +185,56 @@ var LIC_CODE = map[int][]byte{
 
 var cartInstance *CartContext
 
+// NewCartContext creates an empty cartridge and makes it the singleton
+func NewCartContext() *CartContext {
+	cartInstance = &CartContext{
+		romBank:    1,     // Start with ROM bank 1 (bank 0 maps to bank 1)
+		ramBank:    0,     // Start with RAM bank 0
+		ramEnabled: false, // RAM disabled by default
+		bankMode:   0,     // ROM banking mode by default
+	}
+	return cartInstance
+}
+
 // CartCtx returns the singleton CartContext
 func CartCtx() *CartContext {
 	if cartInstance == nil {
-		cartInstance = &CartContext{
-			romBank:    1,     // Start with ROM bank 1 (bank 0 maps to bank 1)
-			ramBank:    0,     // Start with RAM bank 0
-			ramEnabled: false, // RAM disabled by default
-			bankMode:   0,     // ROM banking mode by default
-		}
+		NewCartContext()
 	}
 	return cartInstance
 }
 
 const headerOffset = 0x100
 
-// cartLicName returns the license name based on the license code
-func (c *CartContext) cartLicName() []byte {
-	if c.header.CartType <= 0xA4 {
-		return LIC_CODE[int(c.header.LicCode)]
+// Title returns the game title from the cartridge header
+func (c *CartContext) Title() string {
+	if c.header == nil {
+		return ""
 	}
-	return nil
+	return strings.TrimRight(string(c.header.Title[:]), "\x00")
+}
+
+// RomCRC32 returns the CRC32 of the whole ROM image, used to identify the
+// exact dump a movie or cheat file belongs to
+func (c *CartContext) RomCRC32() uint32 {
+	return crc32.ChecksumIEEE(c.romData)
+}
+
+// cartLicName returns the license name based on the old or new licensee code
+func (c *CartContext) cartLicName() []byte {
+	return []byte(licenseeName(c.header.LicCode, c.header.NewLicCode))
 }
 
 // cartTypeName returns the cartridge type name based on the cartridge type
 func (c *CartContext) cartTypeName() []byte {
-	if c.header.CartType <= 0x22 {
-		return ROM_TYPES[c.header.CartType]
-	}
-	return nil
+	return romTypeName(c.header.CartType)
 }
 
 // checkSumChecker verifies the checksum of the ROM
 func (c *CartContext) checkSumChecker(checksum byte) string {
-	var x uint16 = 0
-	for i := uint16(0x134); i <= 0x14C; i++ {
-		x = x - uint16(c.romData[i]) - 1
-	}
 	var result string
 
-	if byte(x&0xFF) == checksum {
+	if HeaderChecksum(c.romData) == checksum {
 		result = "PASSED"
 	} else {
 		result = "FAILED"
@@ -201,67 +242,57 @@ func (c *CartContext) checkSumChecker(checksum byte) string {
 	return result
 }
 
-func (c *CartContext) loadCart(romName string) {
+func (c *CartContext) loadCart(romName string) ([]error, error) {
 	data, err := os.ReadFile(romName)
 	slog.Info("Loading ROM file:", slog.String("filename", romName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, romError(ErrRomNotFound, "%s", romName)
+	}
 	if err != nil {
-		logger.Fatal("Failed to load ROM file: %v", err)
+		return nil, err
 	}
 
 	copy(c.filename[:], romName)
 
-	c.romData = data
+	// Unpack before patching: patches apply to the ROM, not the archive
+	data, err = ExtractROM(data, c.archiveEntry)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", romName, err)
+	}
 
-	if len(c.romData) == 0 {
-		logger.Fatal("ROM file is empty.")
+	data, err = c.applyPatchFile(romName, data)
+	if err != nil {
+		return nil, err
 	}
 
-	// Read and parse the ROM header from c.romData
+	return c.loadROM(data)
+}
+
+// ramSizes maps the RAM size header byte to bytes of external RAM
+var ramSizes = map[byte]int{
+	0x00: 0,      // No RAM
+	0x01: 2048,   // 2KB
+	0x02: 8192,   // 8KB
+	0x03: 32768,  // 32KB (4 banks of 8KB each)
+	0x04: 131072, // 128KB (16 banks of 8KB each)
+	0x05: 65536,  // 64KB (8 banks of 8KB each)
+}
+
+// parseHeader reads the cartridge header at 0x100-0x14F
+func parseHeader(rom []byte) (*romHeader, error) {
 	headerSize := int(unsafe.Sizeof(romHeader{}))
-	if len(c.romData) < headerOffset+headerSize {
-		logger.Fatal("ROM file is too small to contain a valid header.")
+	if len(rom) < headerOffset+headerSize {
+		return nil, romError(ErrRomTooSmall, "%d bytes, need at least %d for the header", len(rom), headerOffset+headerSize)
 	}
-
-	headerData := c.romData[headerOffset : headerOffset+headerSize]
-	buffer := bytes.NewBuffer(headerData)
+	buffer := bytes.NewBuffer(rom[headerOffset : headerOffset+headerSize])
 	rh := romHeader{}
-	readErr := binary.Read(buffer, binary.LittleEndian, &rh)
-	if readErr != nil {
-		logger.Fatal("binary.Read failed: %v", readErr)
+	if err := binary.Read(buffer, binary.LittleEndian, &rh); err != nil {
+		return nil, err
 	}
-	c.header = &rh
-	c.header.Title[15] = 0 // Null-terminate the title
-
-	// Log ROM information
-	logger.Info("Cartridge Loaded:")
-	logger.Info("Title    : %s", string(c.header.Title[:]))
-	logger.Info("Cartridge Type : %02X (%s)", c.header.CartType, c.cartTypeName())
-	logger.Info("ROM Size : %d KB", 32<<c.header.RomSize)
-	logger.Info("RAM Size : %02X", c.header.RamSize)
-	logger.Info("LIC Code : %02X (%s)", c.header.LicCode, c.cartLicName())
-	logger.Info("ROM Vers : %02X", c.header.Version)
-	logger.Info(
-		"Checksum : %02X (%s)",
-		c.header.Checksum,
-		c.checkSumChecker(c.header.Checksum),
-	)
-
-	// Optionally, log the size of the ROM data
-	logger.Info("ROM data length: %d bytes", len(c.romData))
-
-	c.initializeRAM()
+	return &rh, nil
 }
 
 func (c *CartContext) initializeRAM() {
-	ramSizes := map[byte]int{
-		0x00: 0,      // No RAM
-		0x01: 2048,   // 2KB
-		0x02: 8192,   // 8KB
-		0x03: 32768,  // 32KB (4 banks of 8KB each)
-		0x04: 131072, // 128KB (16 banks of 8KB each)
-		0x05: 65536,  // 64KB (8 banks of 8KB each)
-	}
-
 	ramSize, exists := ramSizes[c.header.RamSize]
 	if !exists {
 		logger.Warn("Unknown RAM size code: %02X, assuming no RAM", c.header.RamSize)
@@ -287,46 +318,96 @@ func (c *CartContext) ProgramLoad(program [][2]uint) {
 	}
 }
 
-// CartLoad loads a cartridge from a file and initializes event processing
-func (c *CartContext) CartLoad(cart string) bool {
+// SetPatchFile selects the IPS/BPS/UPS patch applied by the next CartLoad.
+// Without one, a patch with the ROM's base name is used if present.
+func (c *CartContext) SetPatchFile(path string) {
+	c.patchPath = path
+}
+
+// SetArchiveEntry selects the file loaded from a zip archive by name
+func (c *CartContext) SetArchiveEntry(name string) {
+	c.archiveEntry = name
+}
+
+// applyPatchFile soft-patches the ROM image in memory before the header is
+// parsed, leaving the file on disk untouched
+func (c *CartContext) applyPatchFile(romName string, data []byte) ([]byte, error) {
+	path := c.patchPath
+	if path == "" {
+		path = FindPatch(romName)
+	}
+	if path == "" {
+		return data, nil
+	}
+
+	patch, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read patch: %w", err)
+	}
+	patched, err := ApplyPatch(data, patch)
+	if err != nil {
+		return nil, fmt.Errorf("apply patch %s: %w", path, err)
+	}
+	logger.Info("Applied patch %s (%d -> %d bytes)", path, len(data), len(patched))
+	return patched, nil
+}
+
+// CartLoad loads a cartridge from a file. err is set when the ROM can't be
+// loaded at all; warnings lists header problems (see ValidateROM) that the
+// emulator can run with, leaving it to the frontend to decide whether to.
+func (c *CartContext) CartLoad(cart string) (warnings []error, err error) {
 	copy(c.filename[:], cart)
-	c.loadCart(cart)
-	return true
+	return c.loadCart(cart)
 }
 
-// LoadROMFromBytes loads a ROM directly from a byte slice (for WASM/JS)
-func (c *CartContext) LoadROMFromBytes(romBytes []byte) bool {
-	c.romData = append([]byte(nil), romBytes...)
-	if len(c.romData) == 0 {
-		logger.Fatal("ROM data is empty.")
-		return false
+// LoadROMFromBytes loads a ROM directly from a byte slice (for WASM/JS).
+// Zip and gzip archives are unpacked first. Returns the same warnings and
+// errors as CartLoad.
+func (c *CartContext) LoadROMFromBytes(romBytes []byte) (warnings []error, err error) {
+	data, err := ExtractROM(romBytes, c.archiveEntry)
+	if err != nil {
+		return nil, err
 	}
-	// Read and parse the ROM header from c.romData
-	headerSize := int(unsafe.Sizeof(romHeader{}))
-	if len(c.romData) < headerOffset+headerSize {
-		logger.Fatal("ROM data is too small to contain a valid header.")
-		return false
+	return c.loadROM(data)
+}
+
+// loadROM loads an unpacked, already patched ROM image and parses its header
+func (c *CartContext) loadROM(data []byte) (warnings []error, err error) {
+	if len(data) < minRomSize {
+		return nil, romError(ErrRomTooSmall, "%d bytes, need at least %d for the header", len(data), minRomSize)
 	}
-	headerData := c.romData[headerOffset : headerOffset+headerSize]
-	buffer := bytes.NewBuffer(headerData)
-	rh := romHeader{}
-	err := binary.Read(buffer, binary.LittleEndian, &rh)
+	c.romData = append([]byte(nil), data...)
+
+	// Read and parse the ROM header from c.romData
+	rh, err := parseHeader(c.romData)
 	if err != nil {
-		logger.Fatal("binary.Read failed: %v", err)
-		return false
+		return nil, err
 	}
-	c.header = &rh
+	c.header = rh
 	c.header.Title[15] = 0 // Null-terminate the title
-	logger.Info("Cartridge Loaded from bytes:")
+
+	// Log ROM information
+	logger.Info("Cartridge Loaded:")
 	logger.Info("Title    : %s", string(c.header.Title[:]))
-	logger.Info("Cartridge Type : %02X", c.header.CartType)
+	logger.Info("Cartridge Type : %02X (%s)", c.header.CartType, c.cartTypeName())
 	logger.Info("ROM Size : %d KB", 32<<c.header.RomSize)
 	logger.Info("RAM Size : %02X", c.header.RamSize)
-	logger.Info("LIC Code : %02X", c.header.LicCode)
+	logger.Info("LIC Code : %02X (%s)", c.header.LicCode, c.cartLicName())
 	logger.Info("ROM Vers : %02X", c.header.Version)
+	logger.Info(
+		"Checksum : %02X (%s)",
+		c.header.Checksum,
+		c.checkSumChecker(c.header.Checksum),
+	)
 	logger.Info("ROM data length: %d bytes", len(c.romData))
+
 	c.initializeRAM()
-	return true
+
+	warnings = ValidateROM(c.romData)
+	for _, w := range warnings {
+		logger.Warn("ROM: %v", w)
+	}
+	return warnings, nil
 }
 
 func (c *CartContext) CartWrite(address uint16, data byte) {
@@ -380,24 +461,33 @@ func (c *CartContext) CartWrite(address uint16, data byte) {
 	}
 }
 
+// SetCheats attaches the cheat list whose Game Genie codes patch ROM reads
+func (c *CartContext) SetCheats(cheats *cheat.List) {
+	c.cheats = cheats
+}
+
+// RamBankWrite writes external RAM in the given bank regardless of the
+// current bank and RAM enable state (used by GameShark codes)
+func (c *CartContext) RamBankWrite(bank int, address uint16, data byte) {
+	ramAddr := int(address-0xA000) + (bank * 0x2000)
+	if address >= 0xA000 && address < 0xC000 && ramAddr < len(c.ramData) {
+		c.ramData[ramAddr] = data
+	}
+}
+
+// CurrentRamBank returns the external RAM bank mapped at 0xA000
+func (c *CartContext) CurrentRamBank() int {
+	return c.ramBank
+}
+
 func (c *CartContext) CartRead(address uint16) byte {
-	switch {
-	case address < 0x4000:
-		// ROM Bank 0 (0x0000-0x3FFF) - always reads from bank 0
-		if int(address) < len(c.romData) {
-			return c.romData[address]
-		}
-		return 0xFF
+	if address < 0x8000 && c.cheats != nil {
+		return c.cheats.PatchRead(address, c.romRead(address))
+	}
 
+	switch {
 	case address < 0x8000:
-		// Switchable ROM Bank (0x4000-0x7FFF)
-		bankOffset := c.romBank * 0x4000
-		romAddr := bankOffset + int(address-0x4000)
-		if romAddr < len(c.romData) {
-			return c.romData[romAddr]
-		}
-		logger.Debug("MBC1: ROM read beyond data, bank %d, address %04X", c.romBank, address)
-		return 0xFF
+		return c.romRead(address)
 
 	case address >= 0xA000 && address < 0xC000:
 		// External RAM Read (0xA000-0xBFFF)
@@ -415,3 +505,23 @@ func (c *CartContext) CartRead(address uint16) byte {
 		return 0xFF
 	}
 }
+
+// romRead reads the ROM through the bank mapping
+func (c *CartContext) romRead(address uint16) byte {
+	if address < 0x4000 {
+		// ROM Bank 0 (0x0000-0x3FFF) - always reads from bank 0
+		if int(address) < len(c.romData) {
+			return c.romData[address]
+		}
+		return 0xFF
+	}
+
+	// Switchable ROM Bank (0x4000-0x7FFF)
+	bankOffset := c.romBank * 0x4000
+	romAddr := bankOffset + int(address-0x4000)
+	if romAddr < len(c.romData) {
+		return c.romData[romAddr]
+	}
+	logger.Debug("MBC1: ROM read beyond data, bank %d, address %04X", c.romBank, address)
+	return 0xFF
+}
@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"strconv"
+	"strings"
+)
+
+// newLicenseeMarker in the old licensee byte means the publisher is given by
+// the two-character code at 0x144-0x145 instead
+const newLicenseeMarker = 0x33
+
+// OLD_LIC_CODE maps the single-byte licensee code at 0x14B to publisher names
+var OLD_LIC_CODE = map[int][]byte{
+	0x00: []byte("None"),
+	0x01: []byte("Nintendo"),
+	0x08: []byte("Capcom"),
+	0x09: []byte("HOT-B"),
+	0x0A: []byte("Jaleco"),
+	0x0B: []byte("Coconuts Japan"),
+	0x0C: []byte("Elite Systems"),
+	0x13: []byte("EA (Electronic Arts)"),
+	0x18: []byte("Hudson Soft"),
+	0x19: []byte("ITC Entertainment"),
+	0x1A: []byte("Yanoman"),
+	0x1D: []byte("Japan Clary"),
+	0x1F: []byte("Virgin Games Ltd."),
+	0x24: []byte("PCM Complete"),
+	0x25: []byte("San-X"),
+	0x28: []byte("Kemco"),
+	0x29: []byte("SETA Corporation"),
+	0x30: []byte("Infogrames"),
+	0x31: []byte("Nintendo"),
+	0x32: []byte("Bandai"),
+	0x34: []byte("Konami"),
+	0x35: []byte("HectorSoft"),
+	0x38: []byte("Capcom"),
+	0x39: []byte("Banpresto"),
+	0x3C: []byte("Entertainment Interactive"),
+	0x3E: []byte("Gremlin"),
+	0x41: []byte("Ubi Soft"),
+	0x42: []byte("Atlus"),
+	0x44: []byte("Malibu Interactive"),
+	0x46: []byte("Angel"),
+	0x47: []byte("Spectrum HoloByte"),
+	0x49: []byte("Irem"),
+	0x4A: []byte("Virgin Games Ltd."),
+	0x4D: []byte("Malibu Interactive"),
+	0x4F: []byte("U.S. Gold"),
+	0x50: []byte("Absolute"),
+	0x51: []byte("Acclaim Entertainment"),
+	0x52: []byte("Activision"),
+	0x53: []byte("Sammy USA Corporation"),
+	0x54: []byte("GameTek"),
+	0x55: []byte("Park Place"),
+	0x56: []byte("LJN"),
+	0x57: []byte("Matchbox"),
+	0x59: []byte("Milton Bradley Company"),
+	0x5A: []byte("Mindscape"),
+	0x5B: []byte("Romstar"),
+	0x5C: []byte("Naxat Soft"),
+	0x5D: []byte("Tradewest"),
+	0x60: []byte("Titus Interactive"),
+	0x61: []byte("Virgin Games Ltd."),
+	0x67: []byte("Ocean Software"),
+	0x69: []byte("EA (Electronic Arts)"),
+	0x6E: []byte("Elite Systems"),
+	0x6F: []byte("Electro Brain"),
+	0x70: []byte("Infogrames"),
+	0x71: []byte("Interplay Entertainment"),
+	0x72: []byte("Broderbund"),
+	0x73: []byte("Sculptured Software"),
+	0x75: []byte("The Sales Curve Limited"),
+	0x78: []byte("THQ"),
+	0x79: []byte("Accolade"),
+	0x7A: []byte("Triffix Entertainment"),
+	0x7C: []byte("MicroProse"),
+	0x7F: []byte("Kemco"),
+	0x80: []byte("Misawa Entertainment"),
+	0x83: []byte("LOZC G."),
+	0x86: []byte("Tokuma Shoten"),
+	0x8B: []byte("Bullet-Proof Software"),
+	0x8C: []byte("Vic Tokai Corp."),
+	0x8E: []byte("Ape Inc."),
+	0x8F: []byte("I'Max"),
+	0x91: []byte("Chunsoft Co."),
+	0x92: []byte("Video System"),
+	0x93: []byte("Tsubaraya Productions"),
+	0x95: []byte("Varie"),
+	0x96: []byte("Yonezawa/S'Pal"),
+	0x97: []byte("Kemco"),
+	0x99: []byte("Arc"),
+	0x9A: []byte("Nihon Bussan"),
+	0x9B: []byte("Tecmo"),
+	0x9C: []byte("Imagineer"),
+	0x9D: []byte("Banpresto"),
+	0x9F: []byte("Nova"),
+	0xA1: []byte("Hori Electric"),
+	0xA2: []byte("Bandai"),
+	0xA4: []byte("Konami"),
+	0xA6: []byte("Kawada"),
+	0xA7: []byte("Takara"),
+	0xA9: []byte("Technos Japan"),
+	0xAA: []byte("Broderbund"),
+	0xAC: []byte("Toei Animation"),
+	0xAD: []byte("Toho"),
+	0xAF: []byte("Namco"),
+	0xB0: []byte("Acclaim Entertainment"),
+	0xB1: []byte("ASCII Corporation or Nexsoft"),
+	0xB2: []byte("Bandai"),
+	0xB4: []byte("Square Enix"),
+	0xB6: []byte("HAL Laboratory"),
+	0xB7: []byte("SNK"),
+	0xB9: []byte("Pony Canyon"),
+	0xBA: []byte("Culture Brain"),
+	0xBB: []byte("Sunsoft"),
+	0xBD: []byte("Sony Imagesoft"),
+	0xBF: []byte("Sammy Corporation"),
+	0xC0: []byte("Taito"),
+	0xC2: []byte("Kemco"),
+	0xC3: []byte("Square"),
+	0xC4: []byte("Tokuma Shoten"),
+	0xC5: []byte("Data East"),
+	0xC6: []byte("Tonkin House"),
+	0xC8: []byte("Koei"),
+	0xC9: []byte("UFL"),
+	0xCA: []byte("Ultra Games"),
+	0xCB: []byte("VAP, Inc."),
+	0xCC: []byte("Use Corporation"),
+	0xCD: []byte("Meldac"),
+	0xCE: []byte("Pony Canyon"),
+	0xCF: []byte("Angel"),
+	0xD0: []byte("Taito"),
+	0xD1: []byte("SOFEL"),
+	0xD2: []byte("Quest"),
+	0xD3: []byte("Sigma Enterprises"),
+	0xD4: []byte("ASK Kodansha Co."),
+	0xD6: []byte("Naxat Soft"),
+	0xD7: []byte("Copya System"),
+	0xD9: []byte("Banpresto"),
+	0xDA: []byte("Tomy"),
+	0xDB: []byte("LJN"),
+	0xDD: []byte("Nippon Computer Systems"),
+	0xDE: []byte("Human Ent."),
+	0xDF: []byte("Altron"),
+	0xE0: []byte("Jaleco"),
+	0xE1: []byte("Towa Chiki"),
+	0xE2: []byte("Yutaka"),
+	0xE3: []byte("Varie"),
+	0xE5: []byte("Epoch"),
+	0xE7: []byte("Athena"),
+	0xE8: []byte("Asmik Ace Entertainment"),
+	0xE9: []byte("Natsume"),
+	0xEA: []byte("King Records"),
+	0xEB: []byte("Atlus"),
+	0xEC: []byte("Epic/Sony Records"),
+	0xEE: []byte("IGS"),
+	0xF0: []byte("A Wave"),
+	0xF3: []byte("Extreme Entertainment"),
+	0xFF: []byte("LJN"),
+}
+
+// newLicCodeNonHex holds the new licensee codes that aren't hex digits and so
+// can't be looked up in LIC_CODE
+var newLicCodeNonHex = map[string][]byte{
+	"9H": []byte("Bottom Up"),
+	"BL": []byte("MTO"),
+	"DK": []byte("Kodansha"),
+}
+
+// licenseeName resolves the publisher from the old licensee byte, falling
+// back to the new two-character code when the old byte is 0x33
+func licenseeName(oldCode byte, newCode [2]byte) string {
+	if oldCode != newLicenseeMarker {
+		if name, ok := OLD_LIC_CODE[int(oldCode)]; ok {
+			return string(name)
+		}
+		return ""
+	}
+
+	code := strings.ToUpper(string(newCode[:]))
+	if name, ok := newLicCodeNonHex[code]; ok {
+		return string(name)
+	}
+	if v, err := strconv.ParseUint(code, 16, 8); err == nil {
+		return string(LIC_CODE[int(v)])
+	}
+	return ""
+}
@@ -0,0 +1,346 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// patchExtensions are tried in order when looking for a patch next to a ROM
+var patchExtensions = []string{".bps", ".ups", ".ips"}
+
+var (
+	ErrPatchFormat    = errors.New("unknown patch format")
+	ErrPatchTruncated = errors.New("patch is truncated")
+	ErrPatchCorrupt   = errors.New("patch is corrupt")
+)
+
+// maxPatchTarget bounds the size of a BPS or UPS target. The largest Game
+// Boy ROMs are 8 MiB.
+const maxPatchTarget = 8 << 20
+
+// maxNumberBytes bounds the length of a BPS/UPS variable-length integer,
+// enough for any size or offset below maxPatchTarget
+const maxNumberBytes = 8
+
+// PatchCRCError reports a checksum mismatch in a BPS or UPS patch
+type PatchCRCError struct {
+	What     string // "source", "target" or "patch"
+	Expected uint32
+	Actual   uint32
+}
+
+func (e *PatchCRCError) Error() string {
+	return fmt.Sprintf("%s CRC32 mismatch: patch expects %08X, got %08X", e.What, e.Expected, e.Actual)
+}
+
+// FindPatch returns the path of a .bps, .ups or .ips file with the same base
+// name as the ROM, or "" if there is none
+func FindPatch(romPath string) string {
+	base := strings.TrimSuffix(romPath, filepath.Ext(romPath))
+	for _, ext := range patchExtensions {
+		if _, err := os.Stat(base + ext); err == nil {
+			return base + ext
+		}
+	}
+	return ""
+}
+
+// ApplyPatch applies an IPS, BPS or UPS patch to rom and returns the patched
+// image. The format is detected from the patch header; rom is not modified.
+func ApplyPatch(rom []byte, patch []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(patch, []byte("PATCH")):
+		return applyIPS(rom, patch)
+	case bytes.HasPrefix(patch, []byte("BPS1")):
+		return applyBPS(rom, patch)
+	case bytes.HasPrefix(patch, []byte("UPS1")):
+		return applyUPS(rom, patch)
+	}
+	return nil, ErrPatchFormat
+}
+
+// applyIPS applies an IPS patch: records of a 24-bit offset and 16-bit size
+// followed by data, or size 0 followed by a 16-bit RLE count and fill byte.
+// An optional 24-bit length after the EOF marker truncates the output.
+func applyIPS(rom []byte, patch []byte) ([]byte, error) {
+	out := append([]byte(nil), rom...)
+	pos := 5
+
+	for {
+		if pos+3 > len(patch) {
+			return nil, ErrPatchTruncated
+		}
+		if string(patch[pos:pos+3]) == "EOF" {
+			pos += 3
+			break
+		}
+
+		offset := int(patch[pos])<<16 | int(patch[pos+1])<<8 | int(patch[pos+2])
+		pos += 3
+		if pos+2 > len(patch) {
+			return nil, ErrPatchTruncated
+		}
+		size := int(binary.BigEndian.Uint16(patch[pos:]))
+		pos += 2
+
+		var data []byte
+		if size == 0 {
+			if pos+3 > len(patch) {
+				return nil, ErrPatchTruncated
+			}
+			count := int(binary.BigEndian.Uint16(patch[pos:]))
+			data = bytes.Repeat(patch[pos+2:pos+3], count)
+			pos += 3
+		} else {
+			if pos+size > len(patch) {
+				return nil, ErrPatchTruncated
+			}
+			data = patch[pos : pos+size]
+			pos += size
+		}
+
+		if end := offset + len(data); end > len(out) {
+			out = append(out, make([]byte, end-len(out))...)
+		}
+		copy(out[offset:], data)
+	}
+
+	if pos+3 <= len(patch) {
+		truncate := int(patch[pos])<<16 | int(patch[pos+1])<<8 | int(patch[pos+2])
+		if truncate < len(out) {
+			out = out[:truncate]
+		}
+	}
+	return out, nil
+}
+
+// patchReader reads the variable-length integers used by BPS and UPS
+type patchReader struct {
+	data []byte
+	pos  int
+	end  int // Start of the 12-byte CRC footer
+}
+
+func (r *patchReader) byte() (byte, error) {
+	if r.pos >= r.end {
+		return 0, ErrPatchTruncated
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+// number reads a variable-length integer. Numbers longer than
+// maxNumberBytes or larger than MaxInt32 are rejected, so callers can add
+// them to offsets without overflowing.
+func (r *patchReader) number() (int, error) {
+	data, shift := int64(0), int64(1)
+	for i := 0; i < maxNumberBytes; i++ {
+		x, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		data += int64(x&0x7F) * shift
+		if x&0x80 != 0 {
+			if data > math.MaxInt32 {
+				return 0, fmt.Errorf("%w: number %d out of range", ErrPatchCorrupt, data)
+			}
+			return int(data), nil
+		}
+		shift <<= 7
+		data += shift
+	}
+	return 0, fmt.Errorf("%w: number longer than %d bytes", ErrPatchCorrupt, maxNumberBytes)
+}
+
+// targetSize reads the target size of a BPS or UPS patch
+func (r *patchReader) targetSize() (int, error) {
+	size, err := r.number()
+	if err != nil {
+		return 0, err
+	}
+	if size > maxPatchTarget {
+		return 0, fmt.Errorf("%w: target size %d is larger than %d", ErrPatchCorrupt, size, maxPatchTarget)
+	}
+	return size, nil
+}
+
+// checkFooter validates the patch CRC and the source CRC, and returns the
+// expected target CRC
+func checkFooter(rom []byte, patch []byte) (uint32, error) {
+	if len(patch) < 4+12 {
+		return 0, ErrPatchTruncated
+	}
+	footer := patch[len(patch)-12:]
+	sourceCRC := binary.LittleEndian.Uint32(footer[0:])
+	targetCRC := binary.LittleEndian.Uint32(footer[4:])
+	patchCRC := binary.LittleEndian.Uint32(footer[8:])
+
+	if actual := crc32.ChecksumIEEE(patch[:len(patch)-4]); actual != patchCRC {
+		return 0, &PatchCRCError{What: "patch", Expected: patchCRC, Actual: actual}
+	}
+	if actual := crc32.ChecksumIEEE(rom); actual != sourceCRC {
+		return 0, &PatchCRCError{What: "source", Expected: sourceCRC, Actual: actual}
+	}
+	return targetCRC, nil
+}
+
+func checkTarget(out []byte, targetCRC uint32) ([]byte, error) {
+	if actual := crc32.ChecksumIEEE(out); actual != targetCRC {
+		return nil, &PatchCRCError{What: "target", Expected: targetCRC, Actual: actual}
+	}
+	return out, nil
+}
+
+// applyUPS applies a UPS patch: hunks of a relative offset followed by bytes
+// XORed into the source until a zero byte
+func applyUPS(rom []byte, patch []byte) ([]byte, error) {
+	targetCRC, err := checkFooter(rom, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &patchReader{data: patch, pos: 4, end: len(patch) - 12}
+	if _, err := r.number(); err != nil { // Source size, covered by the source CRC
+		return nil, err
+	}
+	targetSize, err := r.targetSize()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, targetSize)
+	copy(out, rom)
+
+	outPos := 0
+	for r.pos < r.end {
+		skip, err := r.number()
+		if err != nil {
+			return nil, err
+		}
+		outPos += skip
+		if outPos > max(len(rom), len(out)) {
+			return nil, fmt.Errorf("%w: UPS hunk at %d is past the end of the ROM", ErrPatchCorrupt, outPos)
+		}
+		for {
+			x, err := r.byte()
+			if err != nil {
+				return nil, err
+			}
+			if outPos < len(out) {
+				out[outPos] ^= x
+			}
+			outPos++
+			if x == 0 {
+				break
+			}
+		}
+	}
+
+	return checkTarget(out, targetCRC)
+}
+
+// BPS actions
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+// applyBPS applies a BPS patch: a list of actions that copy runs from the
+// source, the patch or earlier output into the target
+func applyBPS(rom []byte, patch []byte) ([]byte, error) {
+	targetCRC, err := checkFooter(rom, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &patchReader{data: patch, pos: 4, end: len(patch) - 12}
+	if _, err := r.number(); err != nil { // Source size, covered by the source CRC
+		return nil, err
+	}
+	targetSize, err := r.targetSize()
+	if err != nil {
+		return nil, err
+	}
+	metadataSize, err := r.number()
+	if err != nil {
+		return nil, err
+	}
+	if metadataSize > r.end-r.pos {
+		return nil, ErrPatchTruncated
+	}
+	r.pos += metadataSize
+
+	out := make([]byte, targetSize)
+	outPos, sourceRel, targetRel := 0, 0, 0
+
+	for r.pos < r.end {
+		data, err := r.number()
+		if err != nil {
+			return nil, err
+		}
+		action, length := data&3, (data>>2)+1
+		if outPos+length > len(out) {
+			return nil, fmt.Errorf("BPS action writes past the target size %d", len(out))
+		}
+
+		switch action {
+		case bpsSourceRead:
+			if outPos+length > len(rom) {
+				return nil, fmt.Errorf("BPS source read past the end of the ROM")
+			}
+			copy(out[outPos:], rom[outPos:outPos+length])
+			outPos += length
+
+		case bpsTargetRead:
+			if r.pos+length > r.end {
+				return nil, ErrPatchTruncated
+			}
+			copy(out[outPos:], patch[r.pos:r.pos+length])
+			r.pos += length
+			outPos += length
+
+		case bpsSourceCopy, bpsTargetCopy:
+			d, err := r.number()
+			if err != nil {
+				return nil, err
+			}
+			delta := d >> 1
+			if d&1 != 0 {
+				delta = -delta
+			}
+
+			if action == bpsSourceCopy {
+				sourceRel += delta
+				if sourceRel < 0 || sourceRel+length > len(rom) {
+					return nil, fmt.Errorf("BPS source copy outside the ROM")
+				}
+				copy(out[outPos:], rom[sourceRel:sourceRel+length])
+				sourceRel += length
+				outPos += length
+			} else {
+				targetRel += delta
+				if targetRel < 0 || targetRel >= outPos {
+					return nil, fmt.Errorf("BPS target copy outside the written output")
+				}
+				// Byte by byte: the source range may overlap the bytes being written
+				for i := 0; i < length; i++ {
+					out[outPos] = out[targetRel]
+					outPos++
+					targetRel++
+				}
+			}
+		}
+	}
+
+	return checkTarget(out, targetCRC)
+}
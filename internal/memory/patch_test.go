@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+// patchNumber encodes a BPS/UPS variable-length integer
+func patchNumber(n int) []byte {
+	var out []byte
+	for {
+		x := byte(n & 0x7F)
+		n >>= 7
+		if n == 0 {
+			return append(out, x|0x80)
+		}
+		out = append(out, x)
+		n--
+	}
+}
+
+func withFooter(body []byte, source []byte, target []byte) []byte {
+	out := append([]byte(nil), body...)
+	out = binary.LittleEndian.AppendUint32(out, crc32.ChecksumIEEE(source))
+	out = binary.LittleEndian.AppendUint32(out, crc32.ChecksumIEEE(target))
+	return binary.LittleEndian.AppendUint32(out, crc32.ChecksumIEEE(out))
+}
+
+func testROM() []byte {
+	rom := make([]byte, 64)
+	for i := range rom {
+		rom[i] = byte(i)
+	}
+	return rom
+}
+
+func TestApplyIPS(t *testing.T) {
+	rom := testROM()
+	patch := []byte("PATCH")
+	patch = append(patch, 0x00, 0x00, 0x02, 0x00, 0x02, 0xAA, 0xBB)       // 2 bytes at 0x02
+	patch = append(patch, 0x00, 0x00, 0x40, 0x00, 0x00, 0x00, 0x03, 0xCC) // RLE x3 at 0x40, grows the ROM
+	patch = append(patch, []byte("EOF")...)
+
+	out, err := ApplyPatch(rom, patch)
+	if err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	want := append(testROM(), 0xCC, 0xCC, 0xCC)
+	want[2], want[3] = 0xAA, 0xBB
+	if !bytes.Equal(out, want) {
+		t.Errorf("got % X\nwant % X", out, want)
+	}
+	if rom[2] != 2 {
+		t.Errorf("source ROM was modified")
+	}
+
+	truncated, err := ApplyPatch(rom, append([]byte("PATCHEOF"), 0x00, 0x00, 0x10))
+	if err != nil || len(truncated) != 0x10 {
+		t.Errorf("truncation: got %d bytes, err %v", len(truncated), err)
+	}
+}
+
+func TestApplyUPS(t *testing.T) {
+	rom := testROM()
+	target := append(testROM(), 0x99)
+	target[5] ^= 0x0F
+	target[6] ^= 0xF0
+
+	body := []byte("UPS1")
+	body = append(body, patchNumber(len(rom))...)
+	body = append(body, patchNumber(len(target))...)
+	body = append(body, patchNumber(5)...)
+	body = append(body, 0x0F, 0xF0, 0x00) // XOR 0x05-0x06, terminator skips 0x07
+	body = append(body, patchNumber(len(rom)-8)...)
+	body = append(body, 0x99, 0x00)
+	patch := withFooter(body, rom, target)
+
+	out, err := ApplyPatch(rom, patch)
+	if err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	if !bytes.Equal(out, target) {
+		t.Errorf("got % X\nwant % X", out, target)
+	}
+
+	var crcErr *PatchCRCError
+	if _, err := ApplyPatch(target, patch); !errors.As(err, &crcErr) || crcErr.What != "source" {
+		t.Errorf("wrong source ROM: got %v, want source CRC error", err)
+	}
+	patch[6] ^= 0xFF
+	if _, err := ApplyPatch(rom, patch); !errors.As(err, &crcErr) || crcErr.What != "patch" {
+		t.Errorf("corrupt patch: got %v, want patch CRC error", err)
+	}
+}
+
+func TestApplyBPS(t *testing.T) {
+	rom := testROM()
+	// Target: first 8 source bytes, "HI", 4 copies of "HI" via target copy,
+	// then source bytes 0x20-0x23
+	target := append([]byte(nil), rom[:8]...)
+	target = append(target, []byte("HIHIHIHIHI")...)
+	target = append(target, rom[0x20:0x24]...)
+
+	action := func(kind int, length int) []byte { return patchNumber((length-1)<<2 | kind) }
+
+	body := []byte("BPS1")
+	body = append(body, patchNumber(len(rom))...)
+	body = append(body, patchNumber(len(target))...)
+	body = append(body, patchNumber(3)...)
+	body = append(body, []byte("meta")[:3]...)
+	body = append(body, action(bpsSourceRead, 8)...)
+	body = append(body, action(bpsTargetRead, 2)...)
+	body = append(body, 'H', 'I')
+	body = append(body, action(bpsTargetCopy, 8)...)
+	body = append(body, patchNumber(8<<1)...) // targetRel 0 -> 8
+	body = append(body, action(bpsSourceCopy, 4)...)
+	body = append(body, patchNumber(0x20<<1)...)
+	patch := withFooter(body, rom, target)
+
+	out, err := ApplyPatch(rom, patch)
+	if err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	if !bytes.Equal(out, target) {
+		t.Errorf("got % X\nwant % X", out, target)
+	}
+
+	bad := withFooter(body, rom, append(target, 0))
+	var crcErr *PatchCRCError
+	if _, err := ApplyPatch(rom, bad); !errors.As(err, &crcErr) || crcErr.What != "target" {
+		t.Errorf("wrong target CRC: got %v, want target CRC error", err)
+	}
+}
+
+func TestApplyPatchUnknownFormat(t *testing.T) {
+	if _, err := ApplyPatch(testROM(), []byte("NOPE")); !errors.Is(err, ErrPatchFormat) {
+		t.Errorf("got %v, want ErrPatchFormat", err)
+	}
+}
+
+func TestApplyPatchCorrupt(t *testing.T) {
+	rom := testROM()
+	header := func(magic string, target int) []byte {
+		body := append([]byte(magic), patchNumber(len(rom))...)
+		return append(body, patchNumber(target)...)
+	}
+	// Ten continuation bytes: longer than any valid number
+	long := bytes.Repeat([]byte{0x7F}, 10)
+
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"UPS huge skip", append(header("UPS1", len(rom)), patchNumber(1<<62)...)},
+		{"UPS skip past ROM", append(header("UPS1", len(rom)), append(patchNumber(1<<20), 0x01, 0x00)...)},
+		{"UPS long number", append(header("UPS1", len(rom)), long...)},
+		{"UPS target too large", header("UPS1", 16<<20)},
+		{"BPS negative target", append([]byte("BPS1"), append(patchNumber(len(rom)), long...)...)},
+		{"BPS target too large", append(header("BPS1", 16<<20), patchNumber(0)...)},
+		{"BPS metadata past end", append(header("BPS1", len(rom)), patchNumber(1<<30)...)},
+		{"BPS huge action", append(append(header("BPS1", len(rom)), patchNumber(0)...), patchNumber(1<<40)...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := withFooter(tt.body, rom, rom)
+			if _, err := ApplyPatch(rom, patch); err == nil {
+				t.Errorf("corrupt patch was accepted")
+			}
+		})
+	}
+}
@@ -17,9 +17,15 @@ type RamContext struct {
 // singleton instance of RamContext
 var ramInstance *RamContext
 
+// NewRamContext creates cleared WRAM/HRAM and makes it the singleton
+func NewRamContext() *RamContext {
+	ramInstance = &RamContext{}
+	return ramInstance
+}
+
 func RamCtx() *RamContext {
 	if ramInstance == nil {
-		ramInstance = &RamContext{}
+		NewRamContext()
 	}
 	return ramInstance
 }
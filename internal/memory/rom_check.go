@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Problems found while loading a ROM. Loading returns them wrapped in a
+// RomError, so callers can test the kind with errors.Is.
+var (
+	ErrRomNotFound     = errors.New("ROM file not found")
+	ErrRomTooSmall     = errors.New("ROM too small")
+	ErrUnknownMapper   = errors.New("unknown mapper")
+	ErrRomSizeMismatch = errors.New("ROM size mismatch")
+	ErrHeaderChecksum  = errors.New("bad header checksum")
+	ErrGlobalChecksum  = errors.New("bad global checksum")
+	ErrLogoMismatch    = errors.New("Nintendo logo mismatch")
+)
+
+// RomError is a ROM loading problem with details
+type RomError struct {
+	Err    error // One of the ErrRom*/Err* kinds above
+	Detail string
+}
+
+func (e *RomError) Error() string {
+	return e.Err.Error() + ": " + e.Detail
+}
+
+func (e *RomError) Unwrap() error {
+	return e.Err
+}
+
+func romError(kind error, format string, args ...interface{}) *RomError {
+	return &RomError{Err: kind, Detail: fmt.Sprintf(format, args...)}
+}
+
+// minRomSize is the smallest image that contains a complete header
+const minRomSize = 0x150
+
+// nintendoLogo is the bitmap at 0x104-0x133 that the boot ROM compares
+// against before starting the cartridge
+var nintendoLogo = []byte{
+	0xCE, 0xED, 0x66, 0x66, 0xCC, 0x0D, 0x00, 0x0B, 0x03, 0x73, 0x00, 0x83, 0x00, 0x0C, 0x00, 0x0D,
+	0x00, 0x08, 0x11, 0x1F, 0x88, 0x89, 0x00, 0x0E, 0xDC, 0xCC, 0x6E, 0xE6, 0xDD, 0xDD, 0xD9, 0x99,
+	0xBB, 0xBB, 0x67, 0x63, 0x6E, 0x0E, 0xEC, 0xCC, 0xDD, 0xDC, 0x99, 0x9F, 0xBB, 0xB9, 0x33, 0x3E,
+}
+
+// HeaderChecksum computes the header checksum over 0x134-0x14C as the boot
+// ROM does
+func HeaderChecksum(rom []byte) byte {
+	var x byte
+	for i := 0x134; i <= 0x14C; i++ {
+		x = x - rom[i] - 1
+	}
+	return x
+}
+
+// GlobalChecksum computes the 16-bit sum of every ROM byte except the global
+// checksum itself (0x14E-0x14F)
+func GlobalChecksum(rom []byte) uint16 {
+	var sum uint16
+	for i, b := range rom {
+		if i != 0x14E && i != 0x14F {
+			sum += uint16(b)
+		}
+	}
+	return sum
+}
+
+// knownMapper reports whether a cartridge type byte is a documented type
+func knownMapper(cartType byte) bool {
+	if int(cartType) < len(ROM_TYPES) {
+		return !bytes.HasSuffix(ROM_TYPES[cartType], []byte("???"))
+	}
+	_, ok := highRomTypes[cartType]
+	return ok
+}
+
+// ValidateROM checks a ROM image against its header. The returned problems
+// don't stop the emulator from running the ROM, but real hardware would
+// refuse it (logo, header checksum) or it may be a bad dump.
+func ValidateROM(rom []byte) []error {
+	if len(rom) < minRomSize {
+		return []error{romError(ErrRomTooSmall, "%d bytes, need at least %d for the header", len(rom), minRomSize)}
+	}
+
+	var problems []error
+
+	if !bytes.Equal(rom[0x104:0x134], nintendoLogo) {
+		problems = append(problems, romError(ErrLogoMismatch, "logo at 0x104 differs from the boot ROM's copy"))
+	}
+
+	cartType := rom[0x147]
+	if !knownMapper(cartType) {
+		problems = append(problems, romError(ErrUnknownMapper, "cartridge type %02X", cartType))
+	}
+
+	romSizeCode := rom[0x148]
+	if romSizeCode > 8 {
+		problems = append(problems, romError(ErrRomSizeMismatch, "unknown ROM size code %02X", romSizeCode))
+	} else if expected := 0x8000 << romSizeCode; expected != len(rom) {
+		problems = append(problems, romError(ErrRomSizeMismatch, "header says %d KB, file is %d bytes", expected/1024, len(rom)))
+	}
+
+	if expected, actual := rom[0x14D], HeaderChecksum(rom); expected != actual {
+		problems = append(problems, romError(ErrHeaderChecksum, "header has %02X, computed %02X", expected, actual))
+	}
+
+	if expected, actual := uint16(rom[0x14E])<<8|uint16(rom[0x14F]), GlobalChecksum(rom); expected != actual {
+		problems = append(problems, romError(ErrGlobalChecksum, "header has %04X, computed %04X", expected, actual))
+	}
+
+	return problems
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+)
+
+// validROM builds a 32 KB ROM with a correct logo and checksums
+func validROM() []byte {
+	rom := make([]byte, 0x8000)
+	copy(rom[0x104:], nintendoLogo)
+	copy(rom[0x134:], "VALID")
+	rom[0x14D] = HeaderChecksum(rom)
+	sum := GlobalChecksum(rom)
+	rom[0x14E], rom[0x14F] = byte(sum>>8), byte(sum)
+	return rom
+}
+
+func TestValidateROM(t *testing.T) {
+	if problems := ValidateROM(validROM()); len(problems) != 0 {
+		t.Fatalf("valid ROM reported problems: %v", problems)
+	}
+
+	tests := []struct {
+		name   string
+		modify func([]byte) []byte
+		want   error
+	}{
+		{"logo", func(r []byte) []byte { r[0x110] ^= 0xFF; return r }, ErrLogoMismatch},
+		{"mapper", func(r []byte) []byte { r[0x147] = 0x14; return r }, ErrUnknownMapper},
+		{"size", func(r []byte) []byte { return append(r, make([]byte, 0x4000)...) }, ErrRomSizeMismatch},
+		{"header checksum", func(r []byte) []byte { r[0x14D]++; return r }, ErrHeaderChecksum},
+		{"global checksum", func(r []byte) []byte { r[0x200] = 0x42; return r }, ErrGlobalChecksum},
+		{"too small", func(r []byte) []byte { return r[:0x100] }, ErrRomTooSmall},
+	}
+	for _, tt := range tests {
+		problems := ValidateROM(tt.modify(validROM()))
+		found := false
+		for _, p := range problems {
+			found = found || errors.Is(p, tt.want)
+		}
+		if !found {
+			t.Errorf("%s: got %v, want %v", tt.name, problems, tt.want)
+		}
+	}
+}
+
+func TestLoadROMErrors(t *testing.T) {
+	if _, err := NewCartContext().CartLoad("does/not/exist.gb"); !errors.Is(err, ErrRomNotFound) {
+		t.Errorf("missing file: got %v, want ErrRomNotFound", err)
+	}
+	if _, err := NewCartContext().LoadROMFromBytes(make([]byte, 0x40)); !errors.Is(err, ErrRomTooSmall) {
+		t.Errorf("short ROM: got %v, want ErrRomTooSmall", err)
+	}
+
+	rom := validROM()
+	rom[0x14D]++
+	warnings, err := NewCartContext().LoadROMFromBytes(rom)
+	if err != nil || len(warnings) != 2 {
+		t.Errorf("bad checksum should load with warnings, got %v, %v", warnings, err)
+	}
+}
+
+func TestReadRomInfo(t *testing.T) {
+	rom := validROM()
+	copy(rom[0x134:], "POKEMON YELLOW\x00")
+	rom[0x143] = 0x80       // CGB enhanced
+	copy(rom[0x144:], "01") // New licensee: Nintendo R&D1
+	rom[0x146] = 0x03       // SGB
+	rom[0x147] = 0x1B       // MBC5+RAM+BATTERY
+	rom[0x149] = 0x03       // 32 KB RAM
+	rom[0x14A] = 0x01       // Overseas
+	rom[0x14B] = 0x33       // Use the new licensee code
+	rom[0x14D] = HeaderChecksum(rom)
+
+	info, err := ReadRomInfo(rom)
+	if err != nil {
+		t.Fatalf("ReadRomInfo: %v", err)
+	}
+	if info.Title != "POKEMON YELLOW" || info.CGB != "enhanced" || !info.SGB {
+		t.Errorf("title/flags: %+v", info)
+	}
+	if info.Mapper != "MBC5+RAM+BATTERY" || info.RomSize != 0x8000 || info.RamSize != 0x8000 {
+		t.Errorf("mapper/sizes: %+v", info)
+	}
+	if info.LicenseeCode != "01" || info.Licensee != "Nintendo R&D1" || info.Destination != "Overseas" {
+		t.Errorf("licensee/destination: %+v", info)
+	}
+	if !info.HeaderChecksum || info.GlobalChecksum {
+		t.Errorf("checksums: header %v global %v, want true false", info.HeaderChecksum, info.GlobalChecksum)
+	}
+
+	rom[0x14B] = 0xA4
+	if info, _ := ReadRomInfo(rom); info.Licensee != "Konami" || info.LicenseeCode != "A4" || info.SGB {
+		t.Errorf("old licensee code: %+v", info)
+	}
+
+	for cartType, mapper := range map[byte]string{
+		0x00: "ROM ONLY",
+		0x23: "unknown",
+		0xFC: "POCKET CAMERA",
+		0xFD: "BANDAI TAMA5",
+		0xFE: "HuC3",
+		0xFF: "HuC1+RAM+BATTERY",
+	} {
+		rom[0x147] = cartType
+		if info, _ := ReadRomInfo(rom); info.Mapper != mapper {
+			t.Errorf("cart type %02X: mapper %q, want %q", cartType, info.Mapper, mapper)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strings"
+)
+
+// RomInfo is a decoded cartridge header, as printed by the rominfo command
+type RomInfo struct {
+	Title          string   `json:"title"`
+	CGB            string   `json:"cgb"` // "none", "enhanced" or "only"
+	SGB            bool     `json:"sgb"`
+	CartType       byte     `json:"cart_type"`
+	Mapper         string   `json:"mapper"`
+	RomSize        int      `json:"rom_size"` // Bytes, from the header
+	FileSize       int      `json:"file_size"`
+	RamSize        int      `json:"ram_size"` // Bytes, -1 if the code is unknown
+	LicenseeCode   string   `json:"licensee_code"`
+	Licensee       string   `json:"licensee"`
+	Destination    string   `json:"destination"`
+	Version        byte     `json:"version"`
+	HeaderChecksum bool     `json:"header_checksum_ok"`
+	GlobalChecksum bool     `json:"global_checksum_ok"`
+	CRC32          uint32   `json:"crc32"`
+	Problems       []string `json:"problems,omitempty"`
+}
+
+// ReadRomInfo decodes the header of a ROM image
+func ReadRomInfo(rom []byte) (*RomInfo, error) {
+	h, err := parseHeader(rom)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &RomInfo{
+		CartType: h.CartType,
+		FileSize: len(rom),
+		RamSize:  -1,
+		Version:  h.Version,
+		SGB:      h.SgbFlag == 0x03 && h.LicCode == newLicenseeMarker,
+		Licensee: licenseeName(h.LicCode, h.NewLicCode),
+		CRC32:    crc32.ChecksumIEEE(rom),
+	}
+
+	// On CGB cartridges the last title byte is the CGB flag
+	title := h.Title[:]
+	switch h.Title[15] {
+	case 0x80:
+		info.CGB = "enhanced"
+		title = title[:15]
+	case 0xC0:
+		info.CGB = "only"
+		title = title[:15]
+	default:
+		info.CGB = "none"
+	}
+	info.Title = strings.TrimRight(string(title), "\x00 ")
+
+	if name := romTypeName(h.CartType); name != nil {
+		info.Mapper = string(name)
+	} else {
+		info.Mapper = "unknown"
+	}
+	if h.RomSize <= 8 {
+		info.RomSize = 0x8000 << h.RomSize
+	}
+	if size, ok := ramSizes[h.RamSize]; ok {
+		info.RamSize = size
+	}
+
+	if h.LicCode == newLicenseeMarker {
+		info.LicenseeCode = string(h.NewLicCode[:])
+	} else {
+		info.LicenseeCode = fmt.Sprintf("%02X", h.LicCode)
+	}
+
+	if h.DestCode == 0x00 {
+		info.Destination = "Japan"
+	} else {
+		info.Destination = "Overseas"
+	}
+
+	info.HeaderChecksum = HeaderChecksum(rom) == h.Checksum
+	info.GlobalChecksum = GlobalChecksum(rom) == uint16(h.GlobalChecksum[0])<<8|uint16(h.GlobalChecksum[1])
+
+	for _, p := range ValidateROM(rom) {
+		info.Problems = append(info.Problems, p.Error())
+	}
+	return info, nil
+}
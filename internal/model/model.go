@@ -0,0 +1,74 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Model is a Game Boy hardware model. The zero value is the DMG.
+type Model int
+
+const (
+	DMG  Model = iota // Game Boy, CPU revisions A-C
+	DMG0              // Early Japanese Game Boy, CPU revision 0
+	MGB               // Game Boy Pocket and Light
+	SGB               // Super Game Boy
+	SGB2              // Super Game Boy 2
+	CGB               // Game Boy Color
+	AGB               // Game Boy Advance
+)
+
+var names = [...]string{"DMG", "DMG0", "MGB", "SGB", "SGB2", "CGB", "AGB"}
+
+func (m Model) String() string {
+	if m < 0 || int(m) >= len(names) {
+		return fmt.Sprintf("Model(%d)", int(m))
+	}
+	return names[m]
+}
+
+// Parse returns the model with the given name, ignoring case
+func Parse(name string) (Model, error) {
+	for i, n := range names {
+		if strings.EqualFold(name, n) {
+			return Model(i), nil
+		}
+	}
+	return DMG, fmt.Errorf("unknown model %q (expected one of %s)", name, strings.Join(names[:], ", "))
+}
+
+var current Model
+
+// Set selects the model being emulated. Components ask Current whenever
+// their behaviour differs between models.
+func Set(m Model) {
+	current = m
+}
+
+// Current returns the model being emulated
+func Current() Model {
+	return current
+}
+
+// IsCgb reports whether the model is a Game Boy Color or one of its
+// successors, which run DMG cartridges in a compatibility mode
+func (m Model) IsCgb() bool {
+	return m >= CGB
+}
+
+// IsSgb reports whether the model is a Super Game Boy
+func (m Model) IsSgb() bool {
+	return m == SGB || m == SGB2
+}
+
+// HasStatWriteBug reports whether writing STAT briefly enables every STAT
+// interrupt source, which the CGB fixed
+func (m Model) HasStatWriteBug() bool {
+	return !m.IsCgb()
+}
+
+// HasOamBug reports whether 16-bit increments and accesses in 0xFE00-0xFEFF
+// during the OAM scan corrupt OAM, which the CGB fixed
+func (m Model) HasOamBug() bool {
+	return !m.IsCgb()
+}
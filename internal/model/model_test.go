@@ -0,0 +1,18 @@
+package model
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	for m := DMG; m <= AGB; m++ {
+		got, err := Parse(m.String())
+		if err != nil || got != m {
+			t.Errorf("Parse(%q) = %v, %v; want %v", m.String(), got, err, m)
+		}
+	}
+	if got, err := Parse("mgb"); err != nil || got != MGB {
+		t.Errorf("Parse(mgb) = %v, %v; want MGB", got, err)
+	}
+	if _, err := Parse("gba"); err == nil {
+		t.Error("Parse(gba) succeeded, want an error")
+	}
+}
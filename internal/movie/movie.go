@@ -0,0 +1,307 @@
+package movie
+
+import (
+	"app/internal/input"
+	"app/internal/model"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+/*
+Movie file format (text, one record per line):
+
+	gomulator-movie 1
+	rom-title TETRIS
+	rom-crc32 46DF91AD
+	start power-on
+	model DMG
+	oam-bug 1
+	cheat 01FF21D0
+	rerecords 3
+	frames 2
+	|........|
+	|.....S..|
+
+model, oam-bug and cheat are optional. They default to the DMG, no OAM bug
+and no cheats, and there is one cheat line per code enabled when recording
+started.
+
+Each frame line holds the joypad state for one emulated frame in the order
+Up, Down, Left, Right, Select, Start, B, A. A pressed button is written as its
+letter (U D L R s S B A), a released one as '.'.
+*/
+
+const (
+	fileMagic   = "gomulator-movie"
+	fileVersion = 1
+
+	// StartPowerOn means playback begins from a freshly booted machine
+	StartPowerOn = "power-on"
+)
+
+var ErrSaveStateStart = errors.New("movie starts from an embedded save state, which is not supported yet")
+
+// frameButtons lists the buttons in frame-line order with their letters
+var frameButtons = [...]struct {
+	button input.Button
+	letter byte
+}{
+	{input.ButtonUp, 'U'},
+	{input.ButtonDown, 'D'},
+	{input.ButtonLeft, 'L'},
+	{input.ButtonRight, 'R'},
+	{input.ButtonSelect, 's'},
+	{input.ButtonStart, 'S'},
+	{input.ButtonB, 'B'},
+	{input.ButtonA, 'A'},
+}
+
+// Settings are the emulator options that change how a movie plays back
+type Settings struct {
+	Model  model.Model
+	OamBug bool
+	Cheats []string // Enabled cheat codes
+}
+
+// Movie is a recording of the joypad state for every frame since the start
+// state, together with the ROM and settings it was recorded with
+type Movie struct {
+	RomTitle  string
+	RomCRC32  uint32
+	Start     string
+	Settings  Settings
+	Rerecords int
+	Frames    []input.State
+}
+
+// New creates an empty power-on movie for the given ROM
+func New(romTitle string, romCRC32 uint32) *Movie {
+	return &Movie{
+		RomTitle: romTitle,
+		RomCRC32: romCRC32,
+		Start:    StartPowerOn,
+	}
+}
+
+// Verify checks that the movie was recorded on the given ROM
+func (m *Movie) Verify(romTitle string, romCRC32 uint32) error {
+	if m.RomCRC32 != romCRC32 {
+		return fmt.Errorf("movie was recorded on %q (CRC32 %08X), loaded ROM is %q (CRC32 %08X)",
+			m.RomTitle, m.RomCRC32, romTitle, romCRC32)
+	}
+	return nil
+}
+
+// VerifySettings checks that the emulator runs with the settings the movie
+// was recorded with
+func (m *Movie) VerifySettings(s Settings) error {
+	if m.Settings.Model != s.Model {
+		return fmt.Errorf("movie was recorded on the %s, emulating the %s (use -model %s)",
+			m.Settings.Model, s.Model, m.Settings.Model)
+	}
+	if m.Settings.OamBug != s.OamBug {
+		return fmt.Errorf("movie was recorded with the OAM bug %s (use -oam-bug=%t)",
+			onOff(m.Settings.OamBug), m.Settings.OamBug)
+	}
+	want, got := slices.Sorted(slices.Values(m.Settings.Cheats)), slices.Sorted(slices.Values(s.Cheats))
+	if !slices.Equal(want, got) {
+		return fmt.Errorf("movie was recorded with cheats [%s], enabled cheats are [%s]",
+			strings.Join(want, " "), strings.Join(got, " "))
+	}
+	return nil
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// Load reads a movie file from disk
+func Load(path string) (*Movie, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Read parses a movie from r
+func Read(r io.Reader) (*Movie, error) {
+	scanner := bufio.NewScanner(r)
+	m := &Movie{}
+	lineNum := 0
+	expectedFrames := -1
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if lineNum == 1 {
+			var version int
+			if _, err := fmt.Sscanf(line, fileMagic+" %d", &version); err != nil {
+				return nil, errors.New("not a gomulator movie file")
+			}
+			if version != fileVersion {
+				return nil, fmt.Errorf("unsupported movie version %d", version)
+			}
+			continue
+		}
+
+		if line[0] == '|' {
+			st, err := decodeFrame(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			m.Frames = append(m.Frames, st)
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "rom-title":
+			m.RomTitle = value
+		case "rom-crc32":
+			crc, err := strconv.ParseUint(value, 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid rom-crc32 %q", lineNum, value)
+			}
+			m.RomCRC32 = uint32(crc)
+		case "start":
+			m.Start = value
+		case "model":
+			md, err := model.Parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			m.Settings.Model = md
+		case "oam-bug":
+			if value != "0" && value != "1" {
+				return nil, fmt.Errorf("line %d: invalid oam-bug %q", lineNum, value)
+			}
+			m.Settings.OamBug = value == "1"
+		case "cheat":
+			m.Settings.Cheats = append(m.Settings.Cheats, value)
+		case "rerecords":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid rerecords %q", lineNum, value)
+			}
+			m.Rerecords = n
+		case "frames":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid frames %q", lineNum, value)
+			}
+			expectedFrames = n
+		default:
+			return nil, fmt.Errorf("line %d: unknown field %q", lineNum, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if lineNum == 0 {
+		return nil, errors.New("empty movie file")
+	}
+	if expectedFrames >= 0 && expectedFrames != len(m.Frames) {
+		return nil, fmt.Errorf("header says %d frames but file contains %d", expectedFrames, len(m.Frames))
+	}
+	if m.Start != StartPowerOn {
+		return nil, ErrSaveStateStart
+	}
+	return m, nil
+}
+
+// Save writes the movie to disk
+func (m *Movie) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	if err := m.Write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write serialises the movie to w
+func (m *Movie) Write(w io.Writer) error {
+	oamBug := 0
+	if m.Settings.OamBug {
+		oamBug = 1
+	}
+	if _, err := fmt.Fprintf(w, "%s %d\nrom-title %s\nrom-crc32 %08X\nstart %s\nmodel %s\noam-bug %d\n",
+		fileMagic, fileVersion, m.RomTitle, m.RomCRC32, m.Start, m.Settings.Model, oamBug); err != nil {
+		return err
+	}
+	for _, code := range m.Settings.Cheats {
+		if _, err := fmt.Fprintf(w, "cheat %s\n", code); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "rerecords %d\nframes %d\n", m.Rerecords, len(m.Frames)); err != nil {
+		return err
+	}
+	for _, st := range m.Frames {
+		if _, err := io.WriteString(w, encodeFrame(st)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeFrame(st input.State) string {
+	buf := make([]byte, 0, len(frameButtons)+2)
+	buf = append(buf, '|')
+	for _, fb := range frameButtons {
+		if st.Pressed(fb.button) {
+			buf = append(buf, fb.letter)
+		} else {
+			buf = append(buf, '.')
+		}
+	}
+	buf = append(buf, '|')
+	return string(buf)
+}
+
+func decodeFrame(line string) (input.State, error) {
+	var st input.State
+	if len(line) != len(frameButtons)+2 || line[len(line)-1] != '|' {
+		return st, fmt.Errorf("malformed frame %q", line)
+	}
+	for i, fb := range frameButtons {
+		switch line[i+1] {
+		case fb.letter:
+			st.Set(fb.button, true)
+		case '.':
+		default:
+			return st, fmt.Errorf("unexpected %q in frame %q", line[i+1], line)
+		}
+	}
+	return st, nil
+}
@@ -0,0 +1,207 @@
+package movie
+
+import (
+	"app/internal/input"
+	"app/internal/model"
+	"bytes"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMovieRoundTrip(t *testing.T) {
+	m := New("TETRIS", 0x46DF91AD)
+	m.Rerecords = 3
+	m.Frames = []input.State{
+		{},
+		{Start: true},
+		{Up: true, Left: true, A: true},
+		{Down: true, Right: true, Select: true, B: true},
+	}
+
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.Contains(buf.String(), "|.....S..|\n") {
+		t.Errorf("unexpected frame encoding:\n%s", buf.String())
+	}
+
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got.RomTitle != m.RomTitle || got.RomCRC32 != m.RomCRC32 || got.Rerecords != 3 || got.Start != StartPowerOn {
+		t.Errorf("header mismatch: got %+v", got)
+	}
+	if len(got.Frames) != len(m.Frames) {
+		t.Fatalf("got %d frames, want %d", len(got.Frames), len(m.Frames))
+	}
+	for i := range m.Frames {
+		if got.Frames[i] != m.Frames[i] {
+			t.Errorf("frame %d: got %+v, want %+v", i, got.Frames[i], m.Frames[i])
+		}
+	}
+}
+
+func TestReadRejects(t *testing.T) {
+	tests := map[string]string{
+		"bad magic":      "not-a-movie 1\n",
+		"bad version":    "gomulator-movie 9\n",
+		"bad frame":      "gomulator-movie 1\nstart power-on\n|..X.....|\n",
+		"frame count":    "gomulator-movie 1\nstart power-on\nframes 2\n|........|\n",
+		"unknown field":  "gomulator-movie 1\nstart power-on\ncolour blue\n",
+		"save state":     "gomulator-movie 1\nstart savestate\n",
+		"empty":          "",
+		"short frame":    "gomulator-movie 1\nstart power-on\n|....|\n",
+		"bad rom-crc32":  "gomulator-movie 1\nrom-crc32 xyz\n",
+		"bad rerecords":  "gomulator-movie 1\nrerecords many\n",
+		"missing header": "|........|\n",
+	}
+	for name, data := range tests {
+		if _, err := Read(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	_, err := Read(strings.NewReader("gomulator-movie 1\nstart savestate\n"))
+	if !errors.Is(err, ErrSaveStateStart) {
+		t.Errorf("save state start: got %v, want ErrSaveStateStart", err)
+	}
+}
+
+func TestSessionPlaybackThenRecord(t *testing.T) {
+	m := New("GAME", 1)
+	m.Frames = []input.State{{A: true}, {B: true}}
+	live := input.State{Start: true}
+
+	ro := NewPlayback(m, "", true)
+	if st := ro.Next(live); st != m.Frames[0] {
+		t.Errorf("frame 0: got %+v", st)
+	}
+	ro.Next(live)
+	if st := ro.Next(live); st != live || ro.Mode != ModeFinished {
+		t.Errorf("read-only past end: got %+v in mode %v", st, ro.Mode)
+	}
+	if len(m.Frames) != 2 {
+		t.Errorf("read-only playback changed the movie")
+	}
+	if err := ro.Branch(); err == nil {
+		t.Errorf("branching a read-only movie should fail")
+	}
+
+	rw := NewPlayback(m, "", false)
+	rw.Next(live)
+	rw.Next(live)
+	if st := rw.Next(live); st != live || rw.Mode != ModeRecord {
+		t.Errorf("read-write past end: got %+v in mode %v", st, rw.Mode)
+	}
+	if len(m.Frames) != 3 || m.Frames[2] != live {
+		t.Errorf("read-write playback should append live input, got %+v", m.Frames)
+	}
+}
+
+func TestSessionBranch(t *testing.T) {
+	m := New("GAME", 1)
+	m.Frames = []input.State{{A: true}, {B: true}, {Up: true}, {Down: true}}
+
+	s := NewPlayback(m, "", false)
+	s.Next(input.State{})
+	if err := s.Branch(); err != nil {
+		t.Fatalf("Branch: %v", err)
+	}
+	if len(m.Frames) != 1 || m.Rerecords != 1 || s.Mode != ModeRecord {
+		t.Fatalf("after branch: %d frames, %d rerecords, mode %v", len(m.Frames), m.Rerecords, s.Mode)
+	}
+
+	s.Next(input.State{Left: true})
+	if len(m.Frames) != 2 || m.Frames[1] != (input.State{Left: true}) {
+		t.Errorf("branch should record from the current frame, got %+v", m.Frames)
+	}
+}
+
+func TestSessionFinishedThenReadWrite(t *testing.T) {
+	m := New("GAME", 1)
+	m.Frames = []input.State{{A: true}, {B: true}}
+
+	s := NewPlayback(m, "", true)
+	s.Next(input.State{})
+	s.Next(input.State{})
+	s.Next(input.State{Up: true})
+	s.Next(input.State{Down: true})
+	if s.Mode != ModeFinished || len(m.Frames) != 2 {
+		t.Fatalf("read-only past end: mode %v, %d frames", s.Mode, len(m.Frames))
+	}
+
+	// The frames played past the end become part of the movie
+	s.ToggleReadOnly()
+	if s.Mode != ModeRecord {
+		t.Fatalf("mode after switching to read-write = %v, want %v", s.Mode, ModeRecord)
+	}
+	want := []input.State{{A: true}, {B: true}, {Up: true}, {Down: true}}
+	if len(m.Frames) != s.Frame || !slices.Equal(m.Frames, want) {
+		t.Fatalf("frames = %+v at frame %d, want %+v", m.Frames, s.Frame, want)
+	}
+
+	if st := s.Next(input.State{Left: true}); st != (input.State{Left: true}) || len(m.Frames) != 5 {
+		t.Errorf("recording after the switch: got %+v, %d frames", st, len(m.Frames))
+	}
+	if err := s.Branch(); err != nil {
+		t.Errorf("Branch: %v", err)
+	}
+}
+
+func TestMovieSettings(t *testing.T) {
+	m := New("GAME", 1)
+	m.Settings = Settings{Model: model.MGB, OamBug: true, Cheats: []string{"01FF21D0", "00A-17B-C49"}}
+
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !reflect.DeepEqual(got.Settings, m.Settings) {
+		t.Errorf("settings = %+v, want %+v", got.Settings, m.Settings)
+	}
+
+	// Movies without the fields were recorded on a plain DMG
+	old, err := Read(strings.NewReader("gomulator-movie 1\nstart power-on\n"))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if err := old.VerifySettings(Settings{}); err != nil {
+		t.Errorf("default settings: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		s    Settings
+		ok   bool
+	}{
+		{"same", Settings{Model: model.MGB, OamBug: true, Cheats: []string{"01FF21D0", "00A-17B-C49"}}, true},
+		{"cheat order", Settings{Model: model.MGB, OamBug: true, Cheats: []string{"00A-17B-C49", "01FF21D0"}}, true},
+		{"model", Settings{Model: model.DMG, OamBug: true, Cheats: []string{"01FF21D0", "00A-17B-C49"}}, false},
+		{"oam bug", Settings{Model: model.MGB, Cheats: []string{"01FF21D0", "00A-17B-C49"}}, false},
+		{"missing cheat", Settings{Model: model.MGB, OamBug: true, Cheats: []string{"01FF21D0"}}, false},
+		{"no cheats", Settings{Model: model.MGB, OamBug: true}, false},
+	}
+	for _, tt := range tests {
+		if err := m.VerifySettings(tt.s); (err == nil) != tt.ok {
+			t.Errorf("%s: VerifySettings = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+
+	for name, data := range map[string]string{
+		"bad model":   "gomulator-movie 1\nmodel NES\n",
+		"bad oam-bug": "gomulator-movie 1\noam-bug yes\n",
+	} {
+		if _, err := Read(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package movie
+
+import (
+	"app/internal/input"
+	"app/internal/logger"
+	"fmt"
+)
+
+// Mode is what a Session does with each emulated frame
+type Mode int
+
+const (
+	ModeRecord   Mode = iota // Append the live input to the movie
+	ModePlay                 // Feed the recorded input to the emulator
+	ModeFinished             // Read-only playback reached the end, live input is used
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeRecord:
+		return "REC"
+	case ModePlay:
+		return "PLAY"
+	default:
+		return "END"
+	}
+}
+
+// Session drives recording or playback of a movie one frame at a time.
+//
+// In read-only mode playback never changes the movie. In read-write mode the
+// movie switches to recording when playback reaches its last frame, and
+// Branch truncates the movie at the current frame and records from there.
+// Switching a finished playback to read-write appends the frames played past
+// the end and carries on recording.
+type Session struct {
+	Movie    *Movie
+	Mode     Mode
+	ReadOnly bool
+	Frame    int // Number of frames emulated since the start state
+
+	path  string
+	dirty bool
+	tail  []input.State // Live input used past the end in ModeFinished
+}
+
+// NewRecording starts recording a new movie that will be saved to path
+func NewRecording(path string, romTitle string, romCRC32 uint32, settings Settings) *Session {
+	m := New(romTitle, romCRC32)
+	m.Settings = settings
+	return &Session{
+		Movie: m,
+		Mode:  ModeRecord,
+		path:  path,
+		dirty: true,
+	}
+}
+
+// NewPlayback plays back m. Changes made in read-write mode are saved to path.
+func NewPlayback(m *Movie, path string, readOnly bool) *Session {
+	return &Session{
+		Movie:    m,
+		Mode:     ModePlay,
+		ReadOnly: readOnly,
+		path:     path,
+	}
+}
+
+// Next returns the joypad state to use for the upcoming frame. live is the
+// input read from the keyboard/gamepad; it is ignored during playback.
+func (s *Session) Next(live input.State) input.State {
+	if s.Mode == ModePlay && s.Frame >= len(s.Movie.Frames) {
+		if s.ReadOnly {
+			logger.Info("Movie: playback finished at frame %d", s.Frame)
+			s.Mode = ModeFinished
+		} else {
+			logger.Info("Movie: playback finished at frame %d, recording", s.Frame)
+			s.Mode = ModeRecord
+		}
+	}
+
+	switch s.Mode {
+	case ModePlay:
+		st := s.Movie.Frames[s.Frame]
+		s.Frame++
+		return st
+	case ModeRecord:
+		s.Movie.Frames = append(s.Movie.Frames, live)
+		s.Frame++
+		s.dirty = true
+		return live
+	default:
+		s.tail = append(s.tail, live)
+		s.Frame++
+		return live
+	}
+}
+
+// Branch discards the movie after the current frame and starts recording
+// from there, counting a rerecord
+func (s *Session) Branch() error {
+	if s.ReadOnly {
+		return fmt.Errorf("movie is read-only")
+	}
+	if s.Frame > len(s.Movie.Frames) {
+		return fmt.Errorf("frame %d is past the end of the movie", s.Frame)
+	}
+
+	s.Movie.Frames = s.Movie.Frames[:s.Frame]
+	s.Movie.Rerecords++
+	s.Mode = ModeRecord
+	s.dirty = true
+	logger.Info("Movie: recording from frame %d (rerecord %d)", s.Frame, s.Movie.Rerecords)
+	return nil
+}
+
+// ToggleReadOnly switches between read-only and read-write playback
+func (s *Session) ToggleReadOnly() {
+	s.ReadOnly = !s.ReadOnly
+	switch {
+	case s.ReadOnly && s.Mode == ModeRecord:
+		// Stop recording, the remaining frames just use live input
+		s.Mode = ModeFinished
+	case !s.ReadOnly && s.Mode == ModeFinished:
+		// Keep the frames played since the end so the movie stays in sync
+		s.Movie.Frames = append(s.Movie.Frames, s.tail...)
+		s.tail = nil
+		s.Mode = ModeRecord
+		s.dirty = true
+		logger.Info("Movie: recording from frame %d", s.Frame)
+	}
+}
+
+// Save writes the movie back to its file if it has changed
+func (s *Session) Save() error {
+	if !s.dirty || s.path == "" {
+		return nil
+	}
+	if err := s.Movie.Save(s.path); err != nil {
+		return err
+	}
+	s.dirty = false
+	logger.Info("Movie: saved %d frames to %s", len(s.Movie.Frames), s.path)
+	return nil
+}
+
+// Status returns a short description for the overlay, e.g. "PLAY 120/3600 RO"
+func (s *Session) Status() string {
+	access := "RW"
+	if s.ReadOnly {
+		access = "RO"
+	}
+	return fmt.Sprintf("%s %d/%d %s", s.Mode, s.Frame, len(s.Movie.Frames), access)
+}
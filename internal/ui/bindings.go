@@ -0,0 +1,281 @@
+package ui
+
+import (
+	"app/internal/input"
+	"app/internal/logger"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// DefaultStickThreshold is how far an analog stick must be pushed before it
+// counts as a D-pad press
+const DefaultStickThreshold = 0.5
+
+// InputConfig is the on-disk/CLI form of the input bindings. Buttons are named
+// as in input.ParseButton, keys use ebiten key names ("X", "Enter",
+// "ArrowUp") and gamepad buttons use the standard layout names below.
+//
+// Example file:
+//
+//	{
+//	  "keys":    {"a": ["X"], "b": ["Z"], "start": ["Enter"]},
+//	  "gamepad": {"a": ["RightRight"], "b": ["RightBottom"]},
+//	  "stick_threshold": 0.5,
+//	  "allow_opposing_directions": false
+//	}
+type InputConfig struct {
+	Keys                    map[string][]string `json:"keys"`
+	Gamepad                 map[string][]string `json:"gamepad"`
+	StickThreshold          float64             `json:"stick_threshold"`
+	AllowOpposingDirections bool                `json:"allow_opposing_directions"`
+}
+
+// standardButtonNames maps config names to ebiten's standard gamepad layout
+var standardButtonNames = map[string]ebiten.StandardGamepadButton{
+	"RightBottom":      ebiten.StandardGamepadButtonRightBottom,
+	"RightRight":       ebiten.StandardGamepadButtonRightRight,
+	"RightLeft":        ebiten.StandardGamepadButtonRightLeft,
+	"RightTop":         ebiten.StandardGamepadButtonRightTop,
+	"FrontTopLeft":     ebiten.StandardGamepadButtonFrontTopLeft,
+	"FrontTopRight":    ebiten.StandardGamepadButtonFrontTopRight,
+	"FrontBottomLeft":  ebiten.StandardGamepadButtonFrontBottomLeft,
+	"FrontBottomRight": ebiten.StandardGamepadButtonFrontBottomRight,
+	"CenterLeft":       ebiten.StandardGamepadButtonCenterLeft,
+	"CenterRight":      ebiten.StandardGamepadButtonCenterRight,
+	"LeftStick":        ebiten.StandardGamepadButtonLeftStick,
+	"RightStick":       ebiten.StandardGamepadButtonRightStick,
+	"LeftTop":          ebiten.StandardGamepadButtonLeftTop,
+	"LeftBottom":       ebiten.StandardGamepadButtonLeftBottom,
+	"LeftLeft":         ebiten.StandardGamepadButtonLeftLeft,
+	"LeftRight":        ebiten.StandardGamepadButtonLeftRight,
+	"CenterCenter":     ebiten.StandardGamepadButtonCenterCenter,
+}
+
+// DefaultInputConfig returns the built-in layout: Z/X/Enter/Tab/arrows on the
+// keyboard and a Nintendo-style face button layout on gamepads
+func DefaultInputConfig() InputConfig {
+	return InputConfig{
+		Keys: map[string][]string{
+			"a":      {"X"},
+			"b":      {"Z"},
+			"start":  {"Enter"},
+			"select": {"Tab"},
+			"up":     {"ArrowUp"},
+			"down":   {"ArrowDown"},
+			"left":   {"ArrowLeft"},
+			"right":  {"ArrowRight"},
+		},
+		Gamepad: map[string][]string{
+			"a":      {"RightRight"},
+			"b":      {"RightBottom"},
+			"start":  {"CenterRight"},
+			"select": {"CenterLeft"},
+			"up":     {"LeftTop"},
+			"down":   {"LeftBottom"},
+			"left":   {"LeftLeft"},
+			"right":  {"LeftRight"},
+		},
+		StickThreshold: DefaultStickThreshold,
+	}
+}
+
+// LoadInputConfig reads a JSON binding file. Buttons missing from the file
+// keep their default bindings.
+func LoadInputConfig(path string) (InputConfig, error) {
+	cfg := DefaultInputConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	var fileCfg InputConfig
+	if err := json.Unmarshal(data, &fileCfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	for button, keys := range fileCfg.Keys {
+		cfg.Keys[button] = keys
+	}
+	for button, pads := range fileCfg.Gamepad {
+		cfg.Gamepad[button] = pads
+	}
+	if fileCfg.StickThreshold > 0 {
+		cfg.StickThreshold = fileCfg.StickThreshold
+	}
+	cfg.AllowOpposingDirections = fileCfg.AllowOpposingDirections
+
+	return cfg, nil
+}
+
+// ApplyBindOverrides applies a comma separated list of command line bindings
+// such as "a=K,b=J,start=Enter|S,pad.a=RightBottom". Alternatives for the
+// same button are separated by '|'.
+func (c *InputConfig) ApplyBindOverrides(spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("invalid binding %q (expected button=key)", entry)
+		}
+
+		target := c.Keys
+		if strings.HasPrefix(name, "pad.") {
+			target = c.Gamepad
+			name = strings.TrimPrefix(name, "pad.")
+		}
+		if _, ok := input.ParseButton(name); !ok {
+			return fmt.Errorf("unknown button %q in binding %q", name, entry)
+		}
+		target[name] = strings.Split(value, "|")
+	}
+	return nil
+}
+
+// Bindings is the resolved form of an InputConfig used every frame
+type Bindings struct {
+	keys           [input.ButtonCount][]ebiten.Key
+	pad            [input.ButtonCount][]ebiten.StandardGamepadButton
+	stickThreshold float64
+	allowOpposing  bool
+
+	gamepads         []ebiten.GamepadID
+	warnedNoLayout   map[ebiten.GamepadID]bool
+	justConnectedBuf []ebiten.GamepadID
+}
+
+// NewBindings resolves key and gamepad button names. Keys used as hotkeys
+// (Space, F3-F9 and 1-9) are rejected.
+func NewBindings(cfg InputConfig) (*Bindings, error) {
+	b := &Bindings{
+		stickThreshold: cfg.StickThreshold,
+		allowOpposing:  cfg.AllowOpposingDirections,
+		warnedNoLayout: make(map[ebiten.GamepadID]bool),
+	}
+	if b.stickThreshold <= 0 || b.stickThreshold >= 1 {
+		b.stickThreshold = DefaultStickThreshold
+	}
+
+	for name, keyNames := range cfg.Keys {
+		button, ok := input.ParseButton(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown button %q", name)
+		}
+		for _, keyName := range keyNames {
+			var key ebiten.Key
+			if err := key.UnmarshalText([]byte(keyName)); err != nil {
+				return nil, fmt.Errorf("button %s: unknown key %q", name, keyName)
+			}
+			if isHotkey(key) {
+				return nil, fmt.Errorf("button %s: key %q is a hotkey", name, keyName)
+			}
+			b.keys[button] = append(b.keys[button], key)
+		}
+	}
+
+	for name, padNames := range cfg.Gamepad {
+		button, ok := input.ParseButton(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown button %q", name)
+		}
+		for _, padName := range padNames {
+			padButton, ok := standardButtonNames[padName]
+			if !ok {
+				return nil, fmt.Errorf("button %s: unknown gamepad button %q", name, padName)
+			}
+			b.pad[button] = append(b.pad[button], padButton)
+		}
+	}
+
+	return b, nil
+}
+
+// updateGamepads tracks gamepads being plugged in and removed
+func (b *Bindings) updateGamepads() {
+	b.justConnectedBuf = inpututil.AppendJustConnectedGamepadIDs(b.justConnectedBuf[:0])
+	for _, id := range b.justConnectedBuf {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			logger.Info("Gamepad connected: %s (id %d)", ebiten.GamepadName(id), id)
+		} else if !b.warnedNoLayout[id] {
+			logger.Warn("Gamepad %s (id %d) has no standard layout mapping and will be ignored", ebiten.GamepadName(id), id)
+			b.warnedNoLayout[id] = true
+		}
+		b.gamepads = append(b.gamepads, id)
+	}
+
+	kept := b.gamepads[:0]
+	for _, id := range b.gamepads {
+		if inpututil.IsGamepadJustDisconnected(id) {
+			logger.Info("Gamepad disconnected (id %d)", id)
+			delete(b.warnedNoLayout, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	b.gamepads = kept
+}
+
+// Poll reads the keyboard and all connected gamepads and returns the
+// resulting joypad state
+func (b *Bindings) Poll() input.State {
+	b.updateGamepads()
+
+	var st input.State
+	for button := input.Button(0); button < input.ButtonCount; button++ {
+		pressed := false
+		for _, key := range b.keys[button] {
+			if ebiten.IsKeyPressed(key) {
+				pressed = true
+				break
+			}
+		}
+		st.Set(button, pressed)
+	}
+
+	for _, id := range b.gamepads {
+		if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+			continue
+		}
+
+		var padState input.State
+		for button := input.Button(0); button < input.ButtonCount; button++ {
+			for _, padButton := range b.pad[button] {
+				if ebiten.IsStandardGamepadButtonPressed(id, padButton) {
+					padState.Set(button, true)
+					break
+				}
+			}
+		}
+
+		h := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+		v := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+		b.applyStick(&padState, h, v)
+
+		st.Merge(padState)
+	}
+
+	return st
+}
+
+// applyStick presses the D-pad directions the left analog stick is pushed
+// past the threshold in
+func (b *Bindings) applyStick(st *input.State, h, v float64) {
+	st.Left = st.Left || h <= -b.stickThreshold
+	st.Right = st.Right || h >= b.stickThreshold
+	st.Up = st.Up || v <= -b.stickThreshold
+	st.Down = st.Down || v >= b.stickThreshold
+}
+
+// AllowOpposing reports whether Left+Right and Up+Down may be pressed together
+func (b *Bindings) AllowOpposing() bool {
+	return b.allowOpposing
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"app/internal/input"
+)
+
+func TestApplyBindOverrides(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		keys map[string][]string
+		pad  map[string][]string
+		err  string
+	}{
+		{"single key", "a=K", map[string][]string{"a": {"K"}}, nil, ""},
+		{"alternatives", "start=Enter|S", map[string][]string{"start": {"Enter", "S"}}, nil, ""},
+		{"gamepad", "pad.a=RightBottom", nil, map[string][]string{"a": {"RightBottom"}}, ""},
+		{"several with spaces", " a=K , b=J ,", map[string][]string{"a": {"K"}, "b": {"J"}}, nil, ""},
+		{"empty", "", nil, nil, ""},
+		{"missing =", "a", nil, nil, "expected button=key"},
+		{"unknown button", "turbo=K", nil, nil, "unknown button"},
+		{"unknown pad button", "pad.turbo=RightBottom", nil, nil, "unknown button"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultInputConfig()
+			want := DefaultInputConfig()
+			for k, v := range tt.keys {
+				want.Keys[k] = v
+			}
+			for k, v := range tt.pad {
+				want.Gamepad[k] = v
+			}
+
+			err := cfg.ApplyBindOverrides(tt.spec)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("config = %+v, want %+v", cfg, want)
+			}
+		})
+	}
+}
+
+func TestNewBindingsRejectsHotkeys(t *testing.T) {
+	for _, key := range []string{"Space", "F3", "F6", "F9", "Digit1", "Digit9"} {
+		cfg := DefaultInputConfig()
+		cfg.Keys["a"] = []string{key}
+		if _, err := NewBindings(cfg); err == nil || !strings.Contains(err.Error(), "hotkey") {
+			t.Errorf("%s: error = %v, want hotkey error", key, err)
+		}
+	}
+
+	for _, key := range []string{"K", "F2", "F10", "Digit0", "Backspace"} {
+		cfg := DefaultInputConfig()
+		cfg.Keys["a"] = []string{key}
+		if _, err := NewBindings(cfg); err != nil {
+			t.Errorf("%s: %v", key, err)
+		}
+	}
+}
+
+func TestNewBindingsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*InputConfig)
+	}{
+		{"unknown key", func(c *InputConfig) { c.Keys["a"] = []string{"NoSuchKey"} }},
+		{"unknown key button", func(c *InputConfig) { c.Keys["turbo"] = []string{"K"} }},
+		{"unknown pad button", func(c *InputConfig) { c.Gamepad["a"] = []string{"Trigger"} }},
+		{"unknown pad target", func(c *InputConfig) { c.Gamepad["turbo"] = []string{"RightBottom"} }},
+	}
+
+	for _, tt := range tests {
+		cfg := DefaultInputConfig()
+		tt.edit(&cfg)
+		if _, err := NewBindings(cfg); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestStickThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold float64
+		h, v      float64
+		want      input.State
+	}{
+		{"centred", 0.5, 0, 0, input.State{}},
+		{"below threshold", 0.5, 0.49, -0.49, input.State{}},
+		{"at threshold", 0.5, 0.5, -0.5, input.State{Right: true, Up: true}},
+		{"left and down", 0.5, -0.9, 0.9, input.State{Left: true, Down: true}},
+		{"invalid threshold uses default", 1.5, DefaultStickThreshold, 0, input.State{Right: true}},
+		{"zero threshold uses default", 0, -DefaultStickThreshold + 0.01, 0, input.State{}},
+	}
+
+	for _, tt := range tests {
+		cfg := DefaultInputConfig()
+		cfg.StickThreshold = tt.threshold
+		b, err := NewBindings(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var st input.State
+		b.applyStick(&st, tt.h, tt.v)
+		if st != tt.want {
+			t.Errorf("%s: state = %+v, want %+v", tt.name, st, tt.want)
+		}
+	}
+}
+
+func TestStickKeepsPressedDirections(t *testing.T) {
+	b, err := NewBindings(DefaultInputConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := input.State{Left: true, Up: true}
+	b.applyStick(&st, 0, 0)
+	if !st.Left || !st.Up {
+		t.Errorf("state = %+v, want Left and Up kept", st)
+	}
+}
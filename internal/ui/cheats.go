@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"app/internal/cheat"
+	"app/internal/memory"
+	"fmt"
+	"strings"
+)
+
+// maxCheatHotkeys is the number of cheats that can be toggled with 1-9
+const maxCheatHotkeys = 9
+
+// SetCheats attaches a cheat list to the PPU, which applies GameShark codes
+// on every V-blank
+func (p *PpuContext) SetCheats(cheats *cheat.List) {
+	p.Cheats = cheats
+}
+
+// SetCheats attaches a cheat list to the cartridge and PPU
+func (e *EmuContext) SetCheats(cheats *cheat.List) {
+	e.Cheats = cheats
+	e.CartCtx.SetCheats(cheats)
+	e.PpuCtx.SetCheats(cheats)
+}
+
+// applyGameShark writes the enabled GameShark codes into cartridge RAM or
+// WRAM. The real device does this from the V-blank interrupt, so it runs on
+// V-blank entry here too, and not at all while the LCD is off. The writes
+// skip the bus so they can't reach the PPU or DMA mid-step.
+func (p *PpuContext) applyGameShark() {
+	if p.Cheats == nil {
+		return
+	}
+
+	for _, c := range p.Cheats.RamWrites() {
+		if c.Address < 0xC000 {
+			cart := memory.CartCtx()
+			bank, ok := c.ExplicitBank()
+			if !ok {
+				bank = cart.CurrentRamBank()
+			}
+			cart.RamBankWrite(bank, c.Address, c.Value)
+			continue
+		}
+		memory.RamCtx().WramWrite(c.Address, c.Value)
+	}
+}
+
+// cheatListText formats the cheat list for the overlay, numbered for the
+// toggle hotkeys
+func cheatListText(cheats *cheat.List) string {
+	if cheats == nil || len(cheats.Cheats) == 0 {
+		return "Cheats: none loaded"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Cheats (1-9 toggle):")
+	for i, c := range cheats.Cheats {
+		if i < maxCheatHotkeys {
+			fmt.Fprintf(&sb, "\n%d %s", i+1, c)
+		} else {
+			fmt.Fprintf(&sb, "\n  %s", c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"app/internal/cheat"
+	"app/internal/memory"
+	"testing"
+)
+
+// startCheatRom runs program from $0150 with a GameShark code writing 42 to
+// C100
+func startCheatRom(t *testing.T, program []byte) *EmuContext {
+	rom := make([]byte, 0x8000)
+	copy(rom[0x100:], []byte{0x00, 0xC3, 0x50, 0x01}) // nop; jp $0150
+	copy(rom[0x134:], "CHEATTEST")
+	copy(rom[0x150:], program)
+
+	emu, _, err := StartEmulatorFromBytes(rom, RomOptions{})
+	if err != nil {
+		t.Fatalf("StartEmulatorFromBytes: %v", err)
+	}
+	cheats := cheat.NewList("")
+	if _, err := cheats.Add("014200C1", ""); err != nil {
+		t.Fatal(err)
+	}
+	emu.SetCheats(cheats)
+	emu.PowerOn()
+	return emu
+}
+
+func TestGameSharkAppliesOnVBlank(t *testing.T) {
+	emu := startCheatRom(t, []byte{
+		0x18, 0xFE, // jr @
+	})
+
+	emu.StepFrame()
+	memory.RamCtx().WramWrite(0xC100, 0)
+	emu.StepFrame()
+	if !LCDCLCDEnable() {
+		t.Fatal("LCD is off")
+	}
+	if got := memory.RamCtx().WramRead(0xC100); got != 0x42 {
+		t.Errorf("C100 = %02X after a V-blank, want 42", got)
+	}
+}
+
+func TestGameSharkIdleWithLcdOff(t *testing.T) {
+	emu := startCheatRom(t, []byte{
+		0xAF,       // xor a
+		0xE0, 0x40, // ldh ($40), a (LCD off)
+		0x18, 0xFE, // jr @
+	})
+
+	emu.StepFrame()
+	memory.RamCtx().WramWrite(0xC100, 0)
+	emu.StepFrame()
+	if LCDCLCDEnable() {
+		t.Fatal("LCD still on")
+	}
+	if got := memory.RamCtx().WramRead(0xC100); got != 0 {
+		t.Errorf("C100 = %02X with the LCD off, want 00: there is no V-blank", got)
+	}
+}
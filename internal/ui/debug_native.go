@@ -9,9 +9,9 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
-func drawDebugInfo(screen *ebiten.Image) {
+func drawDebugInfo(screen *ebiten.Image, status string) {
 	fps := ebiten.ActualFPS()
 	tps := ebiten.ActualTPS()
-	fpsText := fmt.Sprintf("FPS: %.1f TPS: %.1f", fps, tps)
+	fpsText := fmt.Sprintf("FPS: %.1f TPS: %.1f\n%s", fps, tps, status)
 	ebitenutil.DebugPrint(screen, fpsText)
 }
@@ -8,6 +8,6 @@ import (
 
 // In WASM, DebugPrint is very expensive (creates textures for text rendering)
 // So we disable it to improve performance
-func drawDebugInfo(screen *ebiten.Image) {
+func drawDebugInfo(screen *ebiten.Image, status string) {
 	// No-op in WASM for better performance
 }
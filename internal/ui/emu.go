@@ -1,10 +1,12 @@
 package ui
 
 import (
+	"app/internal/cheat"
 	"app/internal/cpu"
 	"app/internal/input"
 	"app/internal/logger"
 	"app/internal/memory"
+	"app/internal/model"
 	"errors"
 	"time"
 )
@@ -31,8 +33,19 @@ type EmuContext struct {
 	timerCtx *cpu.TimerContext
 	dmaCtx   cpu.DMA
 	BusCtx   *memory.Bus
+	Cheats   *cheat.List
+	Model    model.Model // Hardware model being emulated
+	OamBug   bool        // OAM corruption is emulated
 }
 
+// Game Boy timing: the DMG runs at 4194304 Hz and draws one frame every
+// 154 lines × 456 dots, giving a refresh rate of about 59.7275 Hz
+const (
+	CPU_FREQ_HZ      = 4194304
+	CYCLES_PER_FRAME = LINES_PER_FRAME * TICKS_PER_LINE
+	FRAME_RATE_HZ    = float64(CPU_FREQ_HZ) / CYCLES_PER_FRAME
+)
+
 var emuInstance *EmuContext
 
 var ErrEmulationStopped = errors.New("emulation stopped")
@@ -83,12 +96,15 @@ func (e *EmuContext) ExecuteCycles(cpuCycles int) {
 	}
 
 	// Convert requested CPU cycles to machine cycles (1 machine cycle = 4 CPU cycles)
-	remainingMachineCycles := int32((cpuCycles + 3) / 4)
+	remainingMachineCycles := int64((cpuCycles + 3) / 4)
 	if remainingMachineCycles <= 0 {
 		return
 	}
 
 	targetTicks := cpu.Cm.GetCycleTicks() + remainingMachineCycles
+	cpu.Cm.SetDeadline(targetTicks)
+	// Bring the PPU up to date for the frame that is about to be shown
+	defer cpu.Cm.Sync()
 
 	for cpu.Cm.GetCycleTicks() < targetTicks {
 		prevTicks := cpu.Cm.GetCycleTicks()
@@ -100,18 +116,10 @@ func (e *EmuContext) ExecuteCycles(cpuCycles int) {
 			return
 		}
 
+		// The PPU, DMA and timer were already advanced M-cycle by M-cycle
+		// as the CPU accessed the bus
 		consumedTicks := cpu.Cm.GetCycleTicks() - prevTicks
-		if consumedTicks <= 0 {
-			consumedTicks = 1
-		}
-
-		cpuSteps := consumedTicks * 4
-
-		// OPTIMIZED: Batch tick the PPU and DMA instead of looping
-		e.PpuCtx.PpuTickBatch(cpuSteps)
-		e.dmaCtx.DMATickBatch(cpuSteps)
-
-		e.Ticks += uint64(cpuSteps)
+		e.Ticks += uint64(consumedTicks * 4)
 	}
 }
 
@@ -125,8 +133,8 @@ func (e *EmuContext) DelaySDL(ms uint32) {
 }
 
 func (e *EmuContext) LoadROM(romFile string) bool {
-	if !e.CartCtx.CartLoad(romFile) {
-		logger.Error("Failed to load ROM file: %s", romFile)
+	if _, err := e.CartCtx.CartLoad(romFile); err != nil {
+		logger.Error("Failed to load ROM file %s: %v", romFile, err)
 		return false
 	}
 	return true
@@ -134,66 +142,73 @@ func (e *EmuContext) LoadROM(romFile string) bool {
 
 func (e *EmuContext) StepFrame() {
 	// Game Boy frame = 154 lines × 456 cycles per line = 70,224 cycles
-	const LINES_PER_FRAME = 154
-	const TICKS_PER_LINE = 456
-	frameCycles := LINES_PER_FRAME * TICKS_PER_LINE
-	logger.Debug("StepFrame: executing %d cycles for complete frame", frameCycles)
+	logger.Debug("StepFrame: executing %d cycles for complete frame", CYCLES_PER_FRAME)
 	if !e.Running {
 		return
 	}
-	e.ExecuteCycles(frameCycles)
+	e.ExecuteCycles(CYCLES_PER_FRAME)
 }
 
+// handleCpuStop is called when Step reports that the CPU can't continue.
+// STOP mode is not one of those cases: the CPU idles in Step until joypad
+// input wakes it, so only the debug hook ending a test run gets here.
 func (e *EmuContext) handleCpuStop() bool {
-	if e.CpuCtx.IsStopped() {
-		if e.Running {
-			e.Running = false
-			e.Die = false
-			logger.Info("CPU STOP encountered; ending emulation loop")
-		}
-		return true
-	}
 	e.Die = true
 	logger.Debug("CPU has stopped unexpectedly.")
 	return false
 }
 
-func StartEmulator(romFile string) *EmuContext {
-	cartContext := memory.CartCtx()
+// RomOptions controls how StartEmulator loads the ROM file and the hardware
+// it runs on
+type RomOptions struct {
+	PatchFile    string      // IPS/BPS/UPS patch, "" to use one next to the ROM if present
+	ArchiveEntry string      // File to load from a zip archive, "" for the first .gb/.gbc
+	OamBug       bool        // Emulate the DMG OAM corruption bug
+	Model        model.Model // Hardware model, DMG by default
+}
 
-	if !cartContext.CartLoad(romFile) {
-		logger.Fatal("ROM loading failed. Exiting emulator.")
+// StartEmulator loads a ROM file and builds the emulator around it. err is
+// set if the ROM can't be loaded; warnings lists header problems (see
+// memory.ValidateROM) and the caller decides whether to run anyway.
+func StartEmulator(romFile string, opts RomOptions) (emu *EmuContext, warnings []error, err error) {
+	cartContext := memory.NewCartContext()
+	cartContext.SetPatchFile(opts.PatchFile)
+	cartContext.SetArchiveEntry(opts.ArchiveEntry)
+
+	warnings, err = cartContext.CartLoad(romFile)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Continue initializing other components
-	timerContext := cpu.TimerCtx()
-	dmaContext := cpu.DmaCtx()
-	ppuContext := PpuCtx()
-	ramContext := memory.RamCtx()
-
-	ioContext := input.NewIo(nil, timerContext, dmaContext)
-
-	cpuContext := cpu.NewCpuContext(nil) // Bus will be set later
-	busContext := memory.NewBus(cartContext, ramContext, dmaContext, ppuContext, ioContext, cpuContext)
-
-	cpuContext = cpu.NewCpuContext(busContext)
+	return newEmulator(cartContext, opts), warnings, nil
+}
 
-	emuInstance = EmuCtx(cpuContext, cartContext, timerContext, dmaContext, ppuContext, busContext)
+// StartEmulatorFromBytes initializes the emulator from a ROM byte slice (for
+// WASM/JS). The bytes may be a zip or gzip archive; PatchFile is ignored.
+func StartEmulatorFromBytes(romBytes []byte, opts RomOptions) (emu *EmuContext, warnings []error, err error) {
+	cartContext := memory.NewCartContext()
+	cartContext.SetArchiveEntry(opts.ArchiveEntry)
 
-	return emuInstance
+	warnings, err = cartContext.LoadROMFromBytes(romBytes)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	return newEmulator(cartContext, opts), warnings, nil
 }
 
-// StartEmulatorFromBytes initializes the emulator from a ROM byte slice (for WASM/JS)
-func StartEmulatorFromBytes(romBytes []byte) *EmuContext {
-	cartContext := memory.CartCtx()
-	cartContext.LoadROMFromBytes(romBytes)
+// newEmulator builds fresh instances of every component around a loaded
+// cartridge, so that two runs of the same ROM start from identical state
+func newEmulator(cartContext *memory.CartContext, opts RomOptions) *EmuContext {
+	model.Set(opts.Model)
+	cpu.Cm.Reset()
+	input.Init()
 
-	// Continue initializing other components
-	timerContext := cpu.TimerCtx()
-	dmaContext := cpu.DmaCtx()
-	ppuContext := PpuCtx()
-	ramContext := memory.RamCtx()
+	timerContext := cpu.NewTimerContext()
+	dmaContext := cpu.ResetDmaCtx()
+	ppuInstance = NewPpuContext()
+	ppuContext := ppuInstance
+	ramContext := memory.NewRamContext()
 
 	ioContext := input.NewIo(nil, timerContext, dmaContext)
 
@@ -201,8 +216,32 @@ func StartEmulatorFromBytes(romBytes []byte) *EmuContext {
 	busContext := memory.NewBus(cartContext, ramContext, dmaContext, ppuContext, ioContext, cpuContext)
 
 	cpuContext = cpu.NewCpuContext(busContext)
+	oamBug := false
+	if opts.OamBug {
+		if opts.Model.HasOamBug() {
+			cpuContext.SetOamBug(ppuContext)
+			oamBug = true
+		} else {
+			logger.Warn("The %s has no OAM corruption bug, ignoring the option", opts.Model)
+		}
+	}
+	cpu.Cm.Attach(ppuContext, dmaContext)
 
 	emuInstance = EmuCtx(cpuContext, cartContext, timerContext, dmaContext, ppuContext, busContext)
+	emuInstance.Model = opts.Model
+	emuInstance.OamBug = oamBug
 
 	return emuInstance
 }
+
+// PowerOn resets the LCD, connects its registers to the IO bus and runs the
+// simulated boot ROM, leaving the machine in the state a cartridge sees at 0x100
+func (e *EmuContext) PowerOn() {
+	LcdInit()
+
+	input.LcdReadFunc = LcdRead
+	input.LcdWriteFunc = LcdWrite
+
+	bootRomContext := cpu.BootRomCtx()
+	bootRomContext.SimulateBootSequence()
+}
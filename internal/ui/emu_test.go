@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"app/internal/cpu"
+	"app/internal/memory"
+	"app/internal/model"
+	"math"
+	"testing"
+)
+
+// haltTestROM builds a ROM shaped like a typical game loop: the timer runs,
+// and the CPU halts until V-blank, then scrolls the background by one pixel
+func haltTestROM() []byte {
+	rom := make([]byte, 0x8000)
+	rom[0x40] = 0xD9                                  // V-blank: reti
+	rom[0x50] = 0xD9                                  // Timer: reti
+	copy(rom[0x100:], []byte{0x00, 0xC3, 0x50, 0x01}) // nop; jp $0150
+	copy(rom[0x134:], "HALTTEST")
+
+	copy(rom[0x150:], []byte{
+		0x31, 0xFE, 0xFF, // ld sp, $FFFE
+		0x3E, 0x04, // ld a, $04 (timer on, 4096 Hz)
+		0xE0, 0x07, // ldh ($07), a
+		0x3E, 0x05, // ld a, $05 (V-blank and timer)
+		0xE0, 0xFF, // ldh ($FF), a
+		0xFB,             // ei
+		0x76,             // loop: halt
+		0x21, 0x43, 0xFF, // ld hl, $FF43
+		0x34,       // inc (hl)
+		0x18, 0xF9, // jr loop
+	})
+	return rom
+}
+
+// benchmarkFrames reports how many frames per second rom runs at. The event
+// scheduler mostly speeds up HALT-heavy code: natively, the halt loop went
+// from 478 to 839 frames/s while the busy loop stayed at about 440.
+func benchmarkFrames(b *testing.B, rom []byte) {
+	emu, _, err := StartEmulatorFromBytes(rom, RomOptions{})
+	if err != nil {
+		b.Fatalf("StartEmulatorFromBytes: %v", err)
+	}
+	emu.PowerOn()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		emu.StepFrame()
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "frames/s")
+}
+
+func BenchmarkFrameBusyLoop(b *testing.B) {
+	benchmarkFrames(b, movieTestROM())
+}
+
+func BenchmarkFrameHaltLoop(b *testing.B) {
+	benchmarkFrames(b, haltTestROM())
+}
+
+func TestStepFramePastInt32Ticks(t *testing.T) {
+	emu, _, err := StartEmulatorFromBytes(haltTestROM(), RomOptions{})
+	if err != nil {
+		t.Fatalf("StartEmulatorFromBytes: %v", err)
+	}
+	emu.PowerOn()
+
+	// About 34 minutes of emulated time, just short of 2^31 M-cycles
+	cpu.Cm.IncreaseStoppedCycle(math.MaxInt32 - 100 - int32(cpu.Cm.GetCycleTicks()))
+	start := cpu.Cm.GetCycleTicks()
+	for i := 0; i < 3; i++ {
+		emu.StepFrame()
+	}
+	if got, want := cpu.Cm.GetCycleTicks()-start, int64(3*CYCLES_PER_FRAME/4); got < want {
+		t.Errorf("3 frames ran %d M-cycles from tick %d, want at least %d", got, start, want)
+	}
+}
+
+func TestPostBootIoRegisters(t *testing.T) {
+	emu, _, err := StartEmulatorFromBytes(haltTestROM(), RomOptions{})
+	if err != nil {
+		t.Fatalf("StartEmulatorFromBytes: %v", err)
+	}
+	emu.PowerOn()
+
+	// DMG values at 0x100. LY and the mode and LYC bits of STAT depend on
+	// boot ROM timing, which is not simulated, so they are left out.
+	want := map[uint16]byte{
+		0xFF00: 0xCF, 0xFF01: 0x00, 0xFF02: 0x7E, 0xFF03: 0xFF, 0xFF04: 0xAB,
+		0xFF05: 0x00, 0xFF06: 0x00, 0xFF07: 0xF8, 0xFF08: 0xFF, 0xFF0F: 0xE1,
+		0xFF10: 0x80, 0xFF11: 0xBF, 0xFF12: 0xF3, 0xFF13: 0xFF, 0xFF14: 0xBF,
+		0xFF15: 0xFF, 0xFF16: 0x3F, 0xFF17: 0x00, 0xFF18: 0xFF, 0xFF19: 0xBF,
+		0xFF1A: 0x7F, 0xFF1B: 0xFF, 0xFF1C: 0x9F, 0xFF1D: 0xFF, 0xFF1E: 0xBF,
+		0xFF1F: 0xFF, 0xFF20: 0xFF, 0xFF21: 0x00, 0xFF22: 0x00, 0xFF23: 0xBF,
+		0xFF24: 0x77, 0xFF25: 0xF3, 0xFF26: 0xF1, 0xFF27: 0xFF, 0xFF2F: 0xFF,
+		0xFF40: 0x91, 0xFF42: 0x00, 0xFF43: 0x00, 0xFF45: 0x00,
+		0xFF46: 0xFF, 0xFF47: 0xFC, 0xFF4A: 0x00, 0xFF4B: 0x00,
+		0xFF4D: 0xFF, 0xFF4F: 0xFF, 0xFF50: 0xFF, 0xFF51: 0xFF, 0xFF68: 0xFF,
+		0xFF70: 0xFF, 0xFF7F: 0xFF,
+	}
+	bus := memory.BusCtx()
+	for addr, v := range want {
+		if got := bus.BusRead(addr); got != v {
+			t.Errorf("%04X = %02X, want %02X", addr, got, v)
+		}
+	}
+	if got := bus.BusRead(0xFF41) & 0xF8; got != 0x80 {
+		t.Errorf("STAT interrupt selects = %02X, want 80", got)
+	}
+}
+
+func TestBootRegistersByModel(t *testing.T) {
+	defer model.Set(model.DMG)
+
+	tests := []struct {
+		model model.Model
+		cgb   bool // Cartridge supports the CGB
+		want  cpu.CpuRegisters
+		sc    byte
+	}{
+		{model.DMG0, false, cpu.CpuRegisters{A: 0x01, F: 0x00, B: 0xFF, C: 0x13, D: 0x00, E: 0xC1, H: 0x84, L: 0x03}, 0x7E},
+		{model.DMG, false, cpu.CpuRegisters{A: 0x01, F: 0xB0, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D}, 0x7E},
+		{model.MGB, false, cpu.CpuRegisters{A: 0xFF, F: 0xB0, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D}, 0x7E},
+		{model.SGB, false, cpu.CpuRegisters{A: 0x01, F: 0x00, B: 0x00, C: 0x14, D: 0x00, E: 0x00, H: 0xC0, L: 0x60}, 0x7E},
+		{model.SGB2, false, cpu.CpuRegisters{A: 0xFF, F: 0x00, B: 0x00, C: 0x14, D: 0x00, E: 0x00, H: 0xC0, L: 0x60}, 0x7E},
+		{model.CGB, false, cpu.CpuRegisters{A: 0x11, F: 0x80, B: 0x00, C: 0x00, D: 0x00, E: 0x08, H: 0x00, L: 0x7C}, 0x7F},
+		// CGB cartridges run in DMG mode
+		{model.CGB, true, cpu.CpuRegisters{A: 0x11, F: 0x80, B: 0x00, C: 0x00, D: 0x00, E: 0x08, H: 0x00, L: 0x7C}, 0x7F},
+		{model.AGB, true, cpu.CpuRegisters{A: 0x11, F: 0x00, B: 0x01, C: 0x00, D: 0x00, E: 0x08, H: 0x00, L: 0x7C}, 0x7F},
+	}
+	for _, tt := range tests {
+		rom := haltTestROM()
+		if tt.cgb {
+			rom[0x143] = 0x80
+		}
+		rom[0x14D] = 0x01 // Nonzero header checksum
+		emu, _, err := StartEmulatorFromBytes(rom, RomOptions{Model: tt.model})
+		if err != nil {
+			t.Fatalf("StartEmulatorFromBytes: %v", err)
+		}
+		emu.PowerOn()
+
+		want := tt.want
+		want.Sp, want.Pc = 0xFFFE, 0x0100
+		if got := cpu.CpuCtx().Regs; got != want {
+			t.Errorf("%v (CGB cartridge %v): registers %+v, want %+v", tt.model, tt.cgb, got, want)
+		}
+		if got := memory.BusCtx().BusRead(0xFF02); got != tt.sc {
+			t.Errorf("%v: SC = %02X, want %02X", tt.model, got, tt.sc)
+		}
+	}
+}
+
+func TestBootFlagsZeroHeaderChecksum(t *testing.T) {
+	rom := haltTestROM()
+	rom[0x14D] = 0x00
+	emu, _, err := StartEmulatorFromBytes(rom, RomOptions{})
+	if err != nil {
+		t.Fatalf("StartEmulatorFromBytes: %v", err)
+	}
+	emu.PowerOn()
+	if f := cpu.CpuCtx().Regs.F; f != 0x80 {
+		t.Errorf("F = %02X, want 80 with a zero header checksum", f)
+	}
+}
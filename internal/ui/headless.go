@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"app/internal/input"
+	"app/internal/movie"
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// RunHeadless powers on the emulator and runs it without a window, feeding
+// each frame's input from session. frames <= 0 runs until the end of the
+// movie. onFrame, if set, is called after every emulated frame. Returns the
+// number of frames emulated.
+func RunHeadless(e *EmuContext, session *movie.Session, frames int, onFrame func(frame int)) int {
+	e.PowerOn()
+
+	if frames <= 0 {
+		frames = len(session.Movie.Frames) - session.Frame
+	}
+
+	run := 0
+	for run < frames && e.Running && !e.Die {
+		input.SetState(session.Next(input.State{}))
+		e.StepFrame()
+		run++
+		if onFrame != nil {
+			onFrame(session.Frame)
+		}
+	}
+	return run
+}
+
+// MovieSettings returns the options a movie recorded now depends on
+func (e *EmuContext) MovieSettings() movie.Settings {
+	s := movie.Settings{Model: e.Model, OamBug: e.OamBug}
+	if e.Cheats != nil {
+		s.Cheats = e.Cheats.EnabledCodes()
+	}
+	return s
+}
+
+// FrameHash returns the CRC32 of the current video buffer, used to compare
+// runs for determinism
+func (e *EmuContext) FrameHash() uint32 {
+	buf := make([]byte, 4*len(e.PpuCtx.VideBuffer()))
+	for i, px := range e.PpuCtx.VideBuffer() {
+		binary.LittleEndian.PutUint32(buf[i*4:], px)
+	}
+	return crc32.ChecksumIEEE(buf)
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"app/internal/cpu"
 	logger "app/internal/logger"
+	"app/internal/model"
 )
 
 // LcdContext represents the LCD context with all its registers and color palettes.
@@ -13,7 +14,6 @@ type LcdContext struct {
 	ScrollX    uint8
 	Ly         uint8
 	LyCompare  uint8
-	Dma        uint8
 	BgPalette  uint8
 	ObjPalette [2]uint8
 	WinY       uint8
@@ -21,6 +21,8 @@ type LcdContext struct {
 	BgColors   [4]uint32
 	Sp1Colors  [4]uint32
 	Sp2Colors  [4]uint32
+
+	statLine bool // OR of the enabled STAT interrupt sources
 }
 
 var lcdContext LcdContext
@@ -38,11 +40,13 @@ const (
 	ModeXfer   lcdMode = 3 // Pixel transfer
 )
 
-// PPU timing constants - using specific names to avoid conflicts
+// PPU timing constants - using specific names to avoid conflicts. Mode 3
+// only takes PIXEL_XFER_TICKS with SCX%8 = 0, no window and no sprites;
+// each of those lengthen it and shorten H-blank by the same amount.
 const (
 	OAM_SCAN_TICKS   = 80
-	PIXEL_XFER_TICKS = 172
-	HBLANK_TICKS     = 204
+	PIXEL_XFER_TICKS = 172 // Shortest mode 3
+	HBLANK_TICKS     = 204 // Longest H-blank
 )
 
 func LcdInit() {
@@ -53,12 +57,12 @@ func LcdInit() {
 	lcdContext.ScrollY = 0
 	lcdContext.Ly = 0x91 // Boot ROM sets LY to 145 (V-blank)
 	lcdContext.LyCompare = 0
-	lcdContext.Dma = 0
 	lcdContext.BgPalette = 0xFC
 	lcdContext.ObjPalette[0] = 0xFF
 	lcdContext.ObjPalette[1] = 0xFF
 	lcdContext.WinY = 0
 	lcdContext.WinX = 0
+	lcdContext.statLine = false
 
 	for i := 0; i < 4; i++ {
 		lcdContext.BgColors[i] = colorsDefault[i]
@@ -79,16 +83,15 @@ func LcdCtx() *LcdContext {
 	return &lcdContext
 }
 
+// LcdRead reads an LCD register. FF46 is the DMA register and never gets
+// here: input.Io routes it to the DMA unit.
 func LcdRead(address uint16) uint8 {
 	offset := address - 0xFF40
 	switch offset {
 	case 0:
 		return lcdContext.Lcdc
-		if ppuInstance != nil {
-			ppuInstance.WindowLine = 0
-		}
-		// Add cases for other fields as needed.
-		return lcdContext.Lcds
+	case 1:
+		return lcdContext.Lcds | 0x80 // Bit 7 is unused and reads 1
 	case 2:
 		return lcdContext.ScrollY
 	case 3:
@@ -97,8 +100,6 @@ func LcdRead(address uint16) uint8 {
 		return lcdContext.Ly
 	case 5:
 		return lcdContext.LyCompare
-	case 6:
-		return lcdContext.Dma
 	case 7:
 		return lcdContext.BgPalette
 	case 8:
@@ -147,22 +148,36 @@ func LcdWrite(address uint16, value uint8) {
 				logger.Debug("*** BACKGROUND ENABLED! *** LCDC: 0x%02X -> 0x%02X", lcdContext.Lcdc, value)
 			}
 
-			// only when window is actually disabled via LCDC bit 5
-			if (value&0x20) == 0 && (lcdContext.Lcdc&0x20) != 0 {
-				// Window disabled via LCDC bit 5 - reset window line counter
-				ppuInstance.WindowLine = 0
-				logger.Debug("Window disabled via LCDC bit 5 - reset window line counter")
-			}
-
 			// Only log major changes to reduce spam
 			if (value & 0x81) != (lcdContext.Lcdc & 0x81) {
 				logger.Debug("LCD: LCDC write 0x%02X (LCD_EN=%v, BG_EN=%v, OBJ_EN=%v)",
 					value, (value&0x80) != 0, (value&0x01) != 0, (value&0x02) != 0)
 			}
 		}
+		wasOn := LCDCLCDEnable()
 		lcdContext.Lcdc = value
+		if ppuInstance != nil && wasOn != LCDCLCDEnable() {
+			if LCDCLCDEnable() {
+				logger.Debug("LCD: switched on")
+				ppuInstance.StartLCD()
+			} else {
+				if LCDSMode() != ModeVBlank {
+					logger.Debug("LCD: switched off outside V-blank (LY=%d)", lcdContext.Ly)
+				}
+				ppuInstance.ResetLCDState()
+			}
+		}
 	case 1:
-		lcdContext.Lcds = value
+		// DMG bug: for one cycle the write acts as if every source were
+		// enabled, so it raises an interrupt in H-blank, V-blank or on LY=LYC
+		if model.Current().HasStatWriteBug() {
+			lcdContext.Lcds |= uint8(SSHBlank | SSVBlank | SSLyc)
+			UpdateStatLine()
+		}
+
+		// Mode and LY=LYC flag are read-only
+		lcdContext.Lcds = lcdContext.Lcds&0x07 | value&0x78
+		UpdateStatLine()
 	case 2:
 		lcdContext.ScrollY = value
 	case 3:
@@ -171,18 +186,10 @@ func LcdWrite(address uint16, value uint8) {
 		lcdContext.Ly = value
 	case 5:
 		lcdContext.LyCompare = value
-		// CRITICAL FIX: Check for immediate LY=LYC match when LYC is written
-		// DMG-ACID2 sets LYC=8 and expects interrupt to fire when LY=8
-		if lcdContext.Ly == lcdContext.LyCompare && LCDCLCDEnabled() {
-			// Trigger LY=LYC match immediately if conditions are met
-			// This ensures DMG-ACID2 LYC=8 interrupt fires at the right time
-			LCDSLycSet(true)
-			if LCDSStatInt(SSLyc) {
-				cpu.CpuRequestInterrupt(cpu.IT_LCD_STAT)
-			}
+		// The comparison is live, a new LYC can raise the interrupt immediately
+		if LCDCLCDEnabled() {
+			CompareLY()
 		}
-	case 6:
-		lcdContext.Dma = value
 	case 7:
 		lcdContext.BgPalette = value
 	case 8:
@@ -196,12 +203,6 @@ func LcdWrite(address uint16, value uint8) {
 		// Add cases for other fields as needed.
 	}
 
-	if offset == 6 {
-		// 0xFF46 = DMA
-		//TODO
-		//cpu.RestartDMAContext(value)
-	}
-
 	switch address {
 	case 0xFF47:
 		logger.Debug("LCD: Updating background palette to 0x%02X", value)
@@ -307,28 +308,60 @@ func LCDCLCDEnabled() bool {
 	return LCDCLCDEnable()
 }
 
-// SetLCDMode sets the LCD mode and triggers appropriate interrupts
+// SetLCDMode sets the LCD mode and updates the STAT interrupt line
 func SetLCDMode(mode lcdMode) {
 	LCDSModeSet(mode)
+	UpdateStatLine()
+}
+
+// CompareLY updates the LY=LYC flag and the STAT interrupt line
+func CompareLY() {
+	LCDSLycSet(lcdContext.Ly == lcdContext.LyCompare)
+	UpdateStatLine()
+}
+
+// UpdateStatLine recomputes the STAT interrupt line, the OR of all enabled
+// sources, and requests IT_LCD_STAT on its rising edge only. While one
+// source holds the line high, other sources becoming active don't raise
+// another interrupt.
+func UpdateStatLine() {
+	line := statLineLevel(false)
+	if line && !lcdContext.statLine {
+		cpu.CpuRequestInterrupt(cpu.IT_LCD_STAT)
+		logger.Debug("LCD: STAT interrupt requested (STAT=0x%02X, LY=%d)", lcdContext.Lcds, lcdContext.Ly)
+	}
+	lcdContext.statLine = line
+}
+
+// StatVBlankStart handles the start of line 144, where the mode 2 source
+// also fires if enabled even though the PPU goes straight to V-blank
+func StatVBlankStart() {
+	line := statLineLevel(true)
+	if line && !lcdContext.statLine {
+		cpu.CpuRequestInterrupt(cpu.IT_LCD_STAT)
+		logger.Debug("LCD: STAT interrupt requested on V-blank entry")
+	}
+	lcdContext.statLine = line
+}
+
+func statLineLevel(vblankStart bool) bool {
+	if !LCDCLCDEnable() {
+		return false
+	}
 
-	// Check for STAT interrupts based on mode
-	switch mode {
+	switch LCDSMode() {
 	case ModeHBlank:
 		if LCDSStatInt(SSHBlank) {
-			// TODO: Request STAT interrupt when interrupt system is ready
-			logger.Debug("LCD: H-blank STAT interrupt requested")
+			return true
 		}
 	case ModeVBlank:
-		if LCDSStatInt(SSVBlank) {
-			// TODO: Request STAT interrupt when interrupt system is ready
-			logger.Debug("LCD: V-blank STAT interrupt requested")
+		if LCDSStatInt(SSVBlank) || (vblankStart && LCDSStatInt(SSOam)) {
+			return true
 		}
-		// TODO: Request VBlank interrupt when interrupt system is ready
-		logger.Debug("LCD: V-blank interrupt requested")
 	case ModeOam:
 		if LCDSStatInt(SSOam) {
-			// TODO: Request STAT interrupt when interrupt system is ready
-			logger.Debug("LCD: OAM STAT interrupt requested")
+			return true
 		}
 	}
+	return LCDSLyc() && LCDSStatInt(SSLyc)
 }
@@ -0,0 +1,76 @@
+package ui
+
+// The DMG corrupts OAM when the CPU accesses 0xFE00-0xFEFF, or increments or
+// decrements a 16-bit register pointing there, while the PPU scans OAM. OAM
+// is read as 20 rows of 8 bytes, one row per M-cycle, and the row being read
+// gets mixed with the one before it. The first row is never corrupted. The
+// patterns work on 16-bit words and don't depend on the address accessed.
+
+const oamRows = 20
+
+// oamBugRow returns the OAM row the PPU is reading, or -1 outside the OAM scan
+func (p *PpuContext) oamBugRow() int {
+	if !LCDCLCDEnable() || p.LcdStarting || LCDSMode() != ModeOam {
+		return -1
+	}
+	if row := int(p.LineTicks / 4); row < oamRows {
+		return row
+	}
+	return -1
+}
+
+// oamWord returns word i (0-3) of an OAM row
+func (p *PpuContext) oamWord(row, i int) uint16 {
+	addr := 0xFE00 + uint16(row*8+i*2)
+	return uint16(p.OamRead(addr)) | uint16(p.OamRead(addr+1))<<8
+}
+
+func (p *PpuContext) setOamWord(row, i int, value uint16) {
+	addr := 0xFE00 + uint16(row*8+i*2)
+	p.OamWrite(addr, byte(value))
+	p.OamWrite(addr+1, byte(value>>8))
+}
+
+// copyOamWords copies the words of row src from word first on into row dst
+func (p *PpuContext) copyOamWords(dst, src, first int) {
+	for i := first; i < 4; i++ {
+		p.setOamWord(dst, i, p.oamWord(src, i))
+	}
+}
+
+// OamBugWrite corrupts OAM for a write, or an increment or decrement, of an
+// address in 0xFE00-0xFEFF
+func (p *PpuContext) OamBugWrite(address uint16) {
+	row := p.oamBugRow()
+	if row < 1 {
+		return
+	}
+	a, b, c := p.oamWord(row, 0), p.oamWord(row-1, 0), p.oamWord(row-1, 2)
+	p.setOamWord(row, 0, ((a^c)&(b^c))^c)
+	p.copyOamWords(row, row-1, 1)
+}
+
+// OamBugRead corrupts OAM for a read of an address in 0xFE00-0xFEFF
+func (p *PpuContext) OamBugRead(address uint16) {
+	row := p.oamBugRow()
+	if row < 1 {
+		return
+	}
+	a, b, c := p.oamWord(row, 0), p.oamWord(row-1, 0), p.oamWord(row-1, 2)
+	p.setOamWord(row, 0, b|(a&c))
+	p.copyOamWords(row, row-1, 1)
+}
+
+// OamBugReadIncrease corrupts OAM for a read that increments or decrements
+// its address in the same cycle. Away from the first four rows and the last
+// one, the preceding row is first mixed with its neighbours and copied over
+// them; a read corruption follows in every case.
+func (p *PpuContext) OamBugReadIncrease(address uint16) {
+	if row := p.oamBugRow(); row >= 4 && row < oamRows-1 {
+		a, b, c, d := p.oamWord(row-2, 0), p.oamWord(row-1, 0), p.oamWord(row, 0), p.oamWord(row-1, 2)
+		p.setOamWord(row-1, 0, (b&(a|c|d))|(a&c&d))
+		p.copyOamWords(row, row-1, 0)
+		p.copyOamWords(row-2, row-1, 0)
+	}
+	p.OamBugRead(address)
+}
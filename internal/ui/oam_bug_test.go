@@ -0,0 +1,73 @@
+package ui
+
+import "testing"
+
+// newOamBugPpu returns a PPU in the OAM scan reading row, with every OAM
+// byte distinct
+func newOamBugPpu(row int) *PpuContext {
+	p := newTestPpu()
+	for i := uint16(0); i < 0xA0; i++ {
+		p.OamWrite(0xFE00+i, byte(i*0x9D+0x5B))
+	}
+	p.LineTicks = uint32(row * 4)
+	return p
+}
+
+// oamRow returns the four words of an OAM row
+func oamRow(p *PpuContext, row int) [4]uint16 {
+	return [4]uint16{p.oamWord(row, 0), p.oamWord(row, 1), p.oamWord(row, 2), p.oamWord(row, 3)}
+}
+
+func TestOamBugPatterns(t *testing.T) {
+	t.Run("write", func(t *testing.T) {
+		p := newOamBugPpu(2)
+		prev := oamRow(p, 1)
+		p.OamBugWrite(0xFE00)
+		if got, want := oamRow(p, 2), [4]uint16{0xC023, prev[1], prev[2], prev[3]}; got != want {
+			t.Errorf("row 2 = %04X, want %04X", got, want)
+		}
+		if got := oamRow(p, 1); got != prev {
+			t.Errorf("row 1 changed to %04X", got)
+		}
+	})
+
+	t.Run("read", func(t *testing.T) {
+		p := newOamBugPpu(2)
+		prev := oamRow(p, 1)
+		p.OamBugRead(0xFEFF)
+		if got, want := oamRow(p, 2), [4]uint16{0xE063, prev[1], prev[2], prev[3]}; got != want {
+			t.Errorf("row 2 = %04X, want %04X", got, want)
+		}
+	})
+
+	t.Run("read during increase", func(t *testing.T) {
+		p := newOamBugPpu(5)
+		p.OamBugReadIncrease(0xFE00)
+		want := [4]uint16{0x98FB, 0xD235, 0x0C6F, 0x46A9}
+		for row := 3; row <= 5; row++ {
+			if got := oamRow(p, row); got != want {
+				t.Errorf("row %d = %04X, want %04X", row, got, want)
+			}
+		}
+	})
+
+	t.Run("first row", func(t *testing.T) {
+		p := newOamBugPpu(0)
+		before := oamRow(p, 0)
+		p.OamBugWrite(0xFE00)
+		p.OamBugRead(0xFE00)
+		if got := oamRow(p, 0); got != before {
+			t.Errorf("row 0 = %04X, want it untouched", got)
+		}
+	})
+
+	t.Run("outside OAM scan", func(t *testing.T) {
+		p := newOamBugPpu(2)
+		SetLCDMode(ModeXfer)
+		before := oamRow(p, 2)
+		p.OamBugWrite(0xFE00)
+		if got := oamRow(p, 2); got != before {
+			t.Errorf("row 2 = %04X, want it untouched in mode 3", got)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"app/internal/logger"
+	"time"
+)
+
+// maxFramesBehind limits how much emulated time may be owed after a stall
+// (window drag, breakpoint, GC pause) before the backlog is dropped instead
+// of being caught up in one burst
+const maxFramesBehind = 4
+
+// FramePacer converts elapsed real time into emulated CPU cycles and decides
+// how many frames to run on each UI tick. Frames are run while the owed cycle
+// count covers a whole frame, so the emulator averages the DMG refresh rate of
+// ~59.73 Hz regardless of the display refresh rate.
+type FramePacer struct {
+	now func() time.Time // Wall clock, replaced in tests
+
+	lastTime time.Time
+	owed     float64 // Emulated cycles owed to real time
+}
+
+// NewFramePacer creates a pacer driven by the wall clock
+func NewFramePacer() *FramePacer {
+	p := &FramePacer{now: time.Now}
+	p.Reset()
+	return p
+}
+
+// Reset discards any owed time, used after pauses and when leaving uncapped mode
+func (p *FramePacer) Reset() {
+	p.lastTime = p.now()
+	p.owed = 0
+}
+
+// elapsed returns the wall-clock time passed since the previous call
+func (p *FramePacer) elapsed() time.Duration {
+	now := p.now()
+	d := now.Sub(p.lastTime)
+	p.lastTime = now
+	return d
+}
+
+// Advance adds the elapsed wall-clock time, scaled by the speed multiplier,
+// to the owed cycle count
+func (p *FramePacer) Advance(multiplier float64) {
+	p.owed += p.elapsed().Seconds() * CPU_FREQ_HZ * multiplier
+
+	limit := float64(CYCLES_PER_FRAME*maxFramesBehind) * max(multiplier, 1)
+	if p.owed > limit {
+		logger.Debug("FramePacer: dropping %.0f owed cycles", p.owed-limit)
+		p.owed = limit
+	}
+}
+
+// FrameDue reports whether enough cycles are owed to run another frame
+func (p *FramePacer) FrameDue() bool {
+	return p.owed >= CYCLES_PER_FRAME
+}
+
+// Consume records cycles that were actually emulated
+func (p *FramePacer) Consume(cycles uint64) {
+	p.owed -= float64(cycles)
+}
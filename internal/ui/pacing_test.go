@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a wall clock that only moves when told to
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestPacer() (*FramePacer, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	p := NewFramePacer()
+	p.now = clock.now
+	p.Reset()
+	return p, clock
+}
+
+// frameTime is how long one DMG frame lasts, rounded up to the nanosecond
+const frameTime = time.Second*CYCLES_PER_FRAME/CPU_FREQ_HZ + 1
+
+// runDue runs frames while the pacer owes them and returns how many ran
+func runDue(p *FramePacer) int {
+	n := 0
+	for p.FrameDue() {
+		p.Consume(CYCLES_PER_FRAME)
+		n++
+	}
+	return n
+}
+
+func TestFramePacerAccumulates(t *testing.T) {
+	p, clock := newTestPacer()
+
+	// A 60 Hz display runs slightly ahead of the DMG, so now and then a
+	// tick has no frame due; over one second the count matches ~59.73 Hz
+	frames := 0
+	for i := 0; i < 600; i++ {
+		clock.advance(time.Second / 60)
+		p.Advance(1)
+		frames += runDue(p)
+	}
+	if want := 597; frames != want { // 10 s at 59.7275 Hz
+		t.Errorf("ran %d frames in 10 s, want %d", frames, want)
+	}
+
+	// Half a frame of time owes no frame, the other half does
+	p.Reset()
+	clock.advance(frameTime / 2)
+	p.Advance(1)
+	if p.FrameDue() {
+		t.Error("frame due after half a frame of time")
+	}
+	clock.advance(frameTime - frameTime/2)
+	p.Advance(1)
+	if runDue(p) != 1 {
+		t.Error("no frame due after a whole frame of time")
+	}
+}
+
+func TestFramePacerMultiplier(t *testing.T) {
+	p, clock := newTestPacer()
+	clock.advance(frameTime + time.Microsecond)
+	p.Advance(2)
+	if n := runDue(p); n != 2 {
+		t.Errorf("2x: %d frames per frame of time, want 2", n)
+	}
+}
+
+func TestFramePacerClampsBacklog(t *testing.T) {
+	p, clock := newTestPacer()
+
+	// A stall drops the backlog beyond maxFramesBehind
+	clock.advance(time.Second)
+	p.Advance(1)
+	if n := runDue(p); n != maxFramesBehind {
+		t.Errorf("after a 1 s stall: %d frames, want %d", n, maxFramesBehind)
+	}
+
+	// Fast-forward scales the limit
+	clock.advance(time.Second)
+	p.Advance(4)
+	if n := runDue(p); n != maxFramesBehind*4 {
+		t.Errorf("after a 1 s stall at 4x: %d frames, want %d", n, maxFramesBehind*4)
+	}
+}
+
+func TestFramePacerReset(t *testing.T) {
+	p, clock := newTestPacer()
+	clock.advance(3 * frameTime)
+	p.Advance(1)
+
+	// Reset drops owed cycles and the time passed since the last Advance
+	clock.advance(3 * frameTime)
+	p.Reset()
+	if p.FrameDue() {
+		t.Error("frame due right after Reset")
+	}
+	p.Advance(1)
+	if p.FrameDue() {
+		t.Error("time from before Reset was counted")
+	}
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"app/internal/cheat"
 	"app/internal/cpu"
 	logger "app/internal/logger"
 	"bytes"
@@ -26,7 +27,7 @@ type PPU interface {
 	OamRead(address uint16) byte
 	VideBuffer() []uint32
 	PpuTick()
-	PpuTickBatch(ticks int32)
+	SetCheats(cheats *cheat.List)
 }
 
 type PpuContext struct {
@@ -39,10 +40,17 @@ type PpuContext struct {
 	LineEntryArray    [10]OamLineEntry
 	FetchedEntryCount byte
 	FetchedEntries    [3]OamEntry
-	WindowLine        byte
+	WindowLine        byte // Internal window line counter, only advanced on lines showing the window
+	WinYTriggered     bool // LY matched WY with the window enabled at some point this frame
 	CurrentFrame      uint32
 	LineTicks         uint32
 	VideoBuffer       []uint32
+	Cheats            *cheat.List // GameShark codes applied on V-blank entry
+
+	LcdStarting bool // First line after LCDC.7 was set, before pixel transfer
+	BlankFrame  bool // The frame being drawn isn't shown, the first after LCD on
+
+	lastSync int64 // Time the PPU was last brought up to date by the scheduler, in dots
 }
 
 var ppuInstance *PpuContext
@@ -79,6 +87,27 @@ type PixelFifoContext struct {
 	MapY           byte
 	TileY          byte
 	FifoX          byte
+
+	WindowActive bool  // The fetcher is reading the window map
+	WindowDrawn  bool  // The window was shown on this line
+	WinTileX     uint8 // Next window map column to fetch
+	WinDiscard   uint8 // Window pixels left of the screen edge when WX < 7
+
+	FetchDots       uint8         // Dots spent in the current fetch step, each takes two
+	StallDots       uint8         // Dots left before the fetcher starts on this line
+	NextSprite      *OamLineEntry // Next sprite on the line to be fetched, in X order
+	SpriteFetching  bool          // The fetcher is paused for NextSprite
+	SpriteFetchDots uint8
+	SpriteAddr      uint16 // Tile data address of the sprite row being fetched
+	ObjFifo         [8]ObjPixel
+	ObjHead         uint8
+}
+
+// ObjPixel is one entry of the object FIFO. ColorIndex 0 is transparent.
+type ObjPixel struct {
+	ColorIndex uint8
+	Palette    uint8 // 0 = OBP0, 1 = OBP1
+	BgPriority bool  // BG/window colors 1-3 are drawn over the sprite
 }
 
 type FetchState int
@@ -237,6 +266,19 @@ func (p *PpuContext) OamRead(address uint16) byte {
 	return 0xFF
 }
 
+// VramBlocked reports whether the CPU is locked out of VRAM: the PPU is
+// using it during pixel transfer
+func (p *PpuContext) VramBlocked() bool {
+	return LCDCLCDEnable() && LCDSMode() == ModeXfer
+}
+
+// OamBlocked reports whether the CPU is locked out of OAM: the PPU is using
+// it during the OAM scan and pixel transfer
+func (p *PpuContext) OamBlocked() bool {
+	mode := LCDSMode()
+	return LCDCLCDEnable() && (mode == ModeOam || mode == ModeXfer)
+}
+
 // EncodeToBytes encodes an OamEntry to a byte slice
 func EncodeToBytes(entry OamEntry) []byte {
 	buf := &bytes.Buffer{}
@@ -282,12 +324,19 @@ func LCDCTileDataSelect() bool {
 
 // PpuTick steps the PPU forward one cycle (main state machine)
 func (p *PpuContext) PpuTick() {
-	// FIXED: Reference implementation NEVER checks LCD enabled in ppu_tick()
-	// Always process the state machine like the reference
+	// The PPU is frozen at LY 0 while the LCD is off
+	if !LCDCLCDEnable() {
+		return
+	}
 
 	// Increment line ticks FIRST like reference
 	p.LineTicks++
 
+	if p.LcdStarting {
+		p.ModeLcdStart()
+		return
+	}
+
 	// Execute the current LCD mode - EXACTLY like reference ppu_tick()
 	switch LCDSMode() {
 	case ModeOam:
@@ -301,16 +350,69 @@ func (p *PpuContext) PpuTick() {
 	}
 }
 
-// PpuTickBatch processes multiple PPU ticks at once for better performance
-func (p *PpuContext) PpuTickBatch(ticks int32) {
-	for i := int32(0); i < ticks; i++ {
+// advance runs the PPU for dots, jumping over the stretches where the mode
+// handlers have nothing to do
+func (p *PpuContext) advance(dots int64) {
+	for dots > 0 {
+		if !LCDCLCDEnable() {
+			return
+		}
+		if skip := min(int64(p.idleDots()), dots); skip > 0 {
+			p.LineTicks += uint32(skip)
+			dots -= skip
+			continue
+		}
 		p.PpuTick()
+		dots--
 	}
 }
 
-// ResetLCDState resets PPU state when LCD is disabled
+// idleDots returns how many dots can pass before the current mode handler
+// acts again. Mode 3 renders every dot and is never skipped.
+func (p *PpuContext) idleDots() int {
+	ticks := int(p.LineTicks)
+	switch {
+	case p.LcdStarting:
+		return OAM_SCAN_TICKS - 1 - ticks
+	case LCDSMode() == ModeOam:
+		if ticks == 0 {
+			return 0 // Sprites are loaded on the first dot
+		}
+		return OAM_SCAN_TICKS - 1 - ticks
+	case LCDSMode() == ModeHBlank, LCDSMode() == ModeVBlank:
+		return TICKS_PER_LINE - 1 - ticks
+	}
+	return 0
+}
+
+// Sync runs the PPU up to now and returns the time of its next event: the
+// earliest dot at which it may change mode or LY, and so raise an interrupt
+func (p *PpuContext) Sync(now int64) int64 {
+	p.advance(now - p.lastSync)
+	p.lastSync = now
+
+	if !LCDCLCDEnable() {
+		return cpu.Never
+	}
+	ticks := int64(p.LineTicks)
+	xferEnd := int64(OAM_SCAN_TICKS + PIXEL_XFER_TICKS) // Mode 3 can't end sooner
+	switch {
+	case p.LcdStarting, LCDSMode() == ModeOam:
+		return now + xferEnd - ticks
+	case LCDSMode() == ModeXfer:
+		if ticks < xferEnd {
+			return now + xferEnd - ticks
+		}
+		return now + 1
+	}
+	return now + TICKS_PER_LINE - ticks
+}
+
+// ResetLCDState resets PPU state when LCD is disabled: LY reads 0, STAT
+// reports mode 0 and the screen goes blank
 func (p *PpuContext) ResetLCDState() {
 	p.LineTicks = 0
+	p.LcdStarting = false
 	LcdCtx().Ly = 0
 	SetLCDMode(ModeHBlank)
 
@@ -319,21 +421,54 @@ func (p *PpuContext) ResetLCDState() {
 	}
 }
 
+// StartLCD restarts the PPU at line 0 when LCDC.7 is set. The first line
+// skips the OAM scan and is 4 dots short, and the first frame is not shown.
+func (p *PpuContext) StartLCD() {
+	p.LineTicks = 4
+	p.LcdStarting = true
+	p.BlankFrame = true
+	p.WindowLine = 0
+	p.WinYTriggered = false
+	p.Pfc.WindowDrawn = false
+	LcdCtx().Ly = 0
+	LCDSModeSet(ModeHBlank)
+	CompareLY()
+}
+
+// ModeLcdStart runs the first line after the LCD is switched on: STAT
+// reports mode 0 until pixel transfer starts, and no sprites are shown
+func (p *PpuContext) ModeLcdStart() {
+	if p.LineTicks < OAM_SCAN_TICKS {
+		return
+	}
+
+	p.LcdStarting = false
+	p.LineSprites = nil
+	p.LineSpriteCount = 0
+	if LCDCWinEnable() && LcdCtx().Ly == LcdCtx().WinY {
+		p.WinYTriggered = true
+	}
+
+	SetLCDMode(ModeXfer)
+	p.ResetPipelineState()
+}
+
 func (p *PpuContext) ModeOAM() {
 	if p.LineTicks >= OAM_SCAN_TICKS {
 		SetLCDMode(ModeXfer)
 
 		// Reset FIFO state for new line (like reference)
-		p.Pfc.CurFetchState = FS_TILE
-		p.Pfc.LineX = 0
-		p.Pfc.FetchX = 0
-		p.Pfc.PushedX = 0
-		p.Pfc.FifoX = 0
+		p.ResetPipelineState()
 
 		logger.Debug("PPU: Line %d - OAM scan complete, found %d sprites", LcdCtx().Ly, p.LineSpriteCount)
 	}
 
 	if p.LineTicks == 1 {
+		// The window can only appear once LY has matched WY this frame
+		if LCDCWinEnable() && LcdCtx().Ly == LcdCtx().WinY {
+			p.WinYTriggered = true
+		}
+
 		p.LineSprites = nil
 		p.LineSpriteCount = 0
 		p.LoadLineSprites()
@@ -355,30 +490,47 @@ func (p *PpuContext) ModePixelTransfer() {
 		p.PipelineFifoReset()
 		SetLCDMode(ModeHBlank)
 
-		if LCDSStatInt(SSHBlank) {
-			logger.Debug("PPU: H-Blank STAT interrupt requested")
-		}
-
 		logger.Debug("PPU: Line %d - Pixel transfer complete, pushed %d pixels", LcdCtx().Ly, p.Pfc.PushedX)
 	}
 }
 
+// PipelineProcess advances mode 3 by one dot. Sprites pause the background
+// fetcher and the pixel output while they are fetched, and the window
+// restarts the fetcher, so mode 3 gets longer with each of them.
 func (p *PpuContext) PipelineProcess() {
 	// Debug: Log pipeline process calls occasionally
 	if LcdCtx().Ly < 2 && p.LineTicks%200 == 0 {
 		logger.Debug("PPU: PipelineProcess called - LY=%d LineTicks=%d", LcdCtx().Ly, p.LineTicks)
 	}
 
-	p.Pfc.MapY = (LcdCtx().Ly + LcdCtx().ScrollY)
-	p.Pfc.MapX = (p.Pfc.FetchX + LcdCtx().ScrollX)
+	if p.Pfc.StallDots > 0 {
+		p.Pfc.StallDots--
+		return
+	}
+
+	if p.Pfc.SpriteFetching {
+		p.PipelineSpriteFetchStep()
+		return
+	}
 
-	// Don't set it here as it depends on what we're actually rendering
+	if p.windowStartsHere() {
+		p.PipelineStartWindow()
+	}
 
-	// Only fetch on even ticks (every 2 dots)
-	if (p.LineTicks & 1) == 0 {
+	if p.spriteDue() {
+		// The sprite fetch waits until the background fetcher has its tile
+		// data and there are pixels to mix the sprite into
+		if p.Pfc.PixelFifo.size > 0 && (p.Pfc.CurFetchState == FS_DATA1 || p.Pfc.CurFetchState == FS_PUSH) {
+			p.Pfc.SpriteFetching = true
+			p.Pfc.SpriteFetchDots = 0
+			p.PipelineSpriteFetchStep()
+			return
+		}
 		p.PixelFetch()
+		return
 	}
 
+	p.PixelFetch()
 	p.PipelinePushPixel()
 }
 
@@ -397,16 +549,15 @@ func (p *PpuContext) ModeHBlank() {
 
 		if LcdCtx().Ly >= YRES {
 			// Entered V-blank
-			SetLCDMode(ModeVBlank)
+			LCDSModeSet(ModeVBlank)
+			StatVBlankStart()
 
 			// Request V-Blank interrupt (like reference)
 			cpu.CpuRequestInterrupt(cpu.IT_VBLANK)
 			logger.Debug("PPU: V-Blank interrupt requested")
 
-			if LCDSStatInt(SSVBlank) {
-				// Request V-Blank STAT interrupt
-				logger.Debug("PPU: V-Blank STAT interrupt requested")
-			}
+			p.applyGameShark()
+			p.BlankFrame = false
 
 			p.CurrentFrame++
 			logger.Debug("PPU: Entering V-blank at line %d, frame %d", LcdCtx().Ly, p.CurrentFrame)
@@ -427,7 +578,9 @@ func (p *PpuContext) ModeVBlank() {
 			// Frame complete, reset to line 0 and start OAM scan
 			LcdCtx().Ly = 0
 			p.WindowLine = 0 // Reset window line counter for new frame
-			SetLCDMode(ModeOam)
+			p.WinYTriggered = false
+			LCDSModeSet(ModeOam)
+			CompareLY()
 			p.CurrentFrame++
 
 			logger.Debug("PPU: Frame %d complete, starting new frame at line 0", p.CurrentFrame)
@@ -442,6 +595,16 @@ func (p *PpuContext) ResetPipelineState() {
 	p.Pfc.FetchX = 0
 	p.Pfc.FifoX = 0
 	p.Pfc.CurFetchState = FS_TILE
+	p.Pfc.WindowActive = false
+	p.Pfc.WindowDrawn = false
+	p.Pfc.WinTileX = 0
+	p.Pfc.WinDiscard = 0
+	p.Pfc.FetchDots = 0
+	p.Pfc.StallDots = 6 // The first tile fetch of every line is thrown away
+	p.Pfc.NextSprite = p.LineSprites
+	p.Pfc.SpriteFetching = false
+	p.Pfc.ObjFifo = [8]ObjPixel{}
+	p.Pfc.ObjHead = 0
 
 	// Clear the pixel FIFO
 	p.Pfc.PixelFifo.head = 0
@@ -449,13 +612,25 @@ func (p *PpuContext) ResetPipelineState() {
 	p.Pfc.PixelFifo.size = 0
 }
 
-// PixelFetch implements the pixel fetch state machine
+// PixelFetch implements the pixel fetch state machine. Fetch steps take two
+// dots each; pushing is retried every dot until the FIFO is empty.
 func (p *PpuContext) PixelFetch() {
 	// Debug: Log fetch state occasionally
 	if LcdCtx().Ly < 2 && p.LineTicks%200 == 0 {
 		logger.Debug("PPU: PixelFetch state=%d FetchX=%d", p.Pfc.CurFetchState, p.Pfc.FetchX)
 	}
 
+	if p.Pfc.CurFetchState == FS_PUSH {
+		p.PushPixelsToFIFO()
+		return
+	}
+
+	p.Pfc.FetchDots++
+	if p.Pfc.FetchDots < 2 {
+		return
+	}
+	p.Pfc.FetchDots = 0
+
 	switch p.Pfc.CurFetchState {
 	case FS_TILE:
 		p.FetchTileNumber()
@@ -463,59 +638,49 @@ func (p *PpuContext) PixelFetch() {
 		p.FetchTileData0()
 	case FS_DATA1:
 		p.FetchTileData1()
-	case FS_PUSH:
-		p.PushPixelsToFIFO()
 	case FS_IDLE:
 		// Wait state - just advance to next state
 		p.Pfc.CurFetchState = FS_PUSH
 	}
 }
 
-// FetchTileNumber fetches the tile number from the background map
+// FetchTileNumber fetches the tile number from the background or window map
 func (p *PpuContext) FetchTileNumber() {
-
-	windowVisible := false
-	if LCDCWinEnable() && LcdCtx().Ly >= LcdCtx().WinY && LcdCtx().WinX < 167 {
-		windowX := int(LcdCtx().WinX) - 7
-		if windowX <= int(p.Pfc.FetchX) {
-			windowVisible = true
-		}
+	if p.Pfc.WindowActive && !LCDCWinEnable() {
+		// LCDC.5 cleared mid-line: the fetcher goes back to the background
+		p.Pfc.WindowActive = false
 	}
 
-	var mapAddr uint16
-	var tileMapIndex int
-
-	if windowVisible {
-		// Render window tile
-		windowTileY := int(p.WindowLine) / 8
-		windowTileX := (int(p.Pfc.FetchX) - (int(LcdCtx().WinX) - 7)) / 8
-
-		p.Pfc.TileY = byte((int(p.WindowLine) % 8) * 2)
+	if p.Pfc.WindowActive {
+		p.PipelineLoadWindowTile()
+	} else {
+		p.FetchBackgroundTile()
+	}
 
-		// Bounds check
-		if windowTileX >= 0 && windowTileX < 32 {
-			tileMapIndex = windowTileY*32 + windowTileX
-			mapAddr = LCDCWinMapArea()
-		} else {
-			// Outside window bounds, use background
-			windowVisible = false
-		}
+	// CRITICAL: Handle signed tile numbers (like reference implementation)
+	if LCDCBGWDataArea() == 0x8800 {
+		// In 0x8800 mode, tile numbers are signed, so add 128 to convert to unsigned
+		p.Pfc.BgwFetchData[0] += 128
 	}
 
-	if !windowVisible {
-		// Render background tile
-		tileY := (int(LcdCtx().Ly) + int(LcdCtx().ScrollY)) / 8
-		tileX := (int(p.Pfc.FetchX) + int(LcdCtx().ScrollX)) / 8
+	// Move to next fetch state and advance fetch_x (like reference)
+	p.Pfc.CurFetchState = FS_DATA0
+	p.Pfc.FetchX += 8
+}
 
-		p.Pfc.TileY = byte(((int(LcdCtx().Ly) + int(LcdCtx().ScrollY)) % 8) * 2)
+// FetchBackgroundTile fetches the next tile number from the background map
+func (p *PpuContext) FetchBackgroundTile() {
+	tileY := (int(LcdCtx().Ly) + int(LcdCtx().ScrollY)) / 8
+	tileX := (int(p.Pfc.FetchX) + int(LcdCtx().ScrollX)) / 8
 
-		// Wrap around the 32x32 tile map
-		tileY = tileY % 32
-		tileX = tileX % 32
+	p.Pfc.TileY = byte(((int(LcdCtx().Ly) + int(LcdCtx().ScrollY)) % 8) * 2)
 
-		tileMapIndex = tileY*32 + tileX
-		mapAddr = LCDCBgMapArea()
-	}
+	// Wrap around the 32x32 tile map
+	tileY = tileY % 32
+	tileX = tileX % 32
+
+	tileMapIndex := tileY*32 + tileX
+	mapAddr := LCDCBgMapArea()
 
 	// Fetch the tile number
 	p.Pfc.BgwFetchData[0] = p.VramRead(mapAddr + uint16(tileMapIndex))
@@ -525,16 +690,6 @@ func (p *PpuContext) FetchTileNumber() {
 		logger.Debug("PPU: Fetching tile - FetchX=%d tileMapIndex=%d tileNum=0x%02X mapAddr=0x%04X",
 			p.Pfc.FetchX, tileMapIndex, p.Pfc.BgwFetchData[0], mapAddr)
 	}
-
-	// CRITICAL: Handle signed tile numbers (like reference implementation)
-	if LCDCBGWDataArea() == 0x8800 {
-		// In 0x8800 mode, tile numbers are signed, so add 128 to convert to unsigned
-		p.Pfc.BgwFetchData[0] += 128
-	}
-
-	// Move to next fetch state and advance fetch_x (like reference)
-	p.Pfc.CurFetchState = FS_DATA0
-	p.Pfc.FetchX += 8
 }
 
 // FetchTileData0 fetches the first byte of tile data
@@ -567,17 +722,14 @@ func (p *PpuContext) FetchTileData1() {
 
 // PushPixelsToFIFO pushes 8 pixels from the fetched tile data to the FIFO
 func (p *PpuContext) PushPixelsToFIFO() {
-	// Only push if FIFO has space (like reference implementation)
-	if p.Pfc.PixelFifo.size > 8 {
-		// FIFO is full, can't add more pixels
+	// The background FIFO only takes a new tile once it is empty
+	if p.Pfc.PixelFifo.size > 0 {
 		return
 	}
 
 	byte1 := p.Pfc.BgwFetchData[1]
 	byte2 := p.Pfc.BgwFetchData[2]
 
-	x := int(p.Pfc.FetchX) - (8 - int(LcdCtx().ScrollX%8))
-
 	// Extract 8 pixels from the tile data
 	for i := 0; i < 8; i++ {
 		bit := 7 - i
@@ -590,21 +742,15 @@ func (p *PpuContext) PushPixelsToFIFO() {
 		pixelColor := LcdCtx().BgColors[colorIndex]
 
 		// This matches reference implementation: if (!LCDC_BGW_ENABLE) color = bg_colors[0];
-		// ALSO: Force disable background for lines 8-15 to hide mohawk hair (DMG-ACID2 test)
 		if !LCDCBGWEnable() {
 			pixelColor = LcdCtx().BgColors[0]
 			colorIndex = 0
 		}
 
-		if x >= 0 {
-			p.PixelFifoPushWithIndex(uint32(pixelColor), colorIndex)
-			p.Pfc.FifoX++
-		}
-
-		x++
+		p.PixelFifoPushWithIndex(uint32(pixelColor), colorIndex)
+		p.Pfc.FifoX++
 	}
 
-	// Return success so fetch state can advance
 	p.Pfc.CurFetchState = FS_TILE
 }
 
@@ -655,147 +801,44 @@ func (p *PpuContext) PixelFifoPop() PixelData {
 	}
 }
 
-// PipelinePushPixel pushes a pixel from the FIFO to the video buffer
+// PipelinePushPixel shifts one pixel out of the FIFOs, mixes the sprite pixel
+// over the background and writes it to the video buffer
 func (p *PpuContext) PipelinePushPixel() {
-	if p.Pfc.PixelFifo.size > 8 {
-		currentLine := LcdCtx().Ly
-
-		// Only render visible scanlines
-		if currentLine < YRES {
-			// Get background pixel from FIFO
-			bgPixel := p.PixelFifoPop()
-			finalPixel := bgPixel
-
-			// Handle scroll X - only start rendering after scroll offset
-			if p.Pfc.LineX >= (LcdCtx().ScrollX % 8) {
-				// Check for sprites at this position if sprites are enabled
-				if LCDCObjEnable() {
-					spritePixel := p.GetSpritePixel(p.Pfc.PushedX, currentLine)
-					if spritePixel.Present {
-						// Handle sprite-to-background priority (CRITICAL FOR DMG-ACID2)
-						if spritePixel.Priority {
-							// Sprite has priority, always show sprite
-							finalPixel = PixelData{Color: spritePixel.Color, ColorIndex: 0, IsBgColor0: false}
-						} else {
-							// Sprite only shows through color 0 of background (FBgp = 1)
-							if bgPixel.IsBgColor0 {
-								finalPixel = PixelData{Color: spritePixel.Color, ColorIndex: 0, IsBgColor0: false}
-							}
-							// Otherwise keep background pixel
-						}
-					}
-				}
-
-				bufferIndex := uint32(currentLine)*XRES + uint32(p.Pfc.PushedX)
-
-				// Bounds check
-				if bufferIndex < uint32(len(p.VideoBuffer)) && p.Pfc.PushedX < XRES {
-					p.VideoBuffer[bufferIndex] = finalPixel.Color
-
-					// Debug: Log pixel writes very occasionally
-					if p.Pfc.PushedX%80 == 0 && currentLine%60 == 0 {
-						logger.Debug("PPU: Wrote pixel 0x%08X at (%d,%d) index=%d FIFO_size=%d",
-							finalPixel.Color, p.Pfc.PushedX, currentLine, bufferIndex, p.Pfc.PixelFifo.size)
-					}
-				}
-
-				p.Pfc.PushedX++
-			}
-		}
+	if p.Pfc.PixelFifo.size == 0 {
+		return
+	}
 
+	bgPixel := p.PixelFifoPop()
+
+	// SCX fine scroll: the first SCX%8 pixels of the line are dropped
+	if p.Pfc.LineX < LcdCtx().ScrollX%8 {
 		p.Pfc.LineX++
+		return
+	}
 
-		// Debug: Log pixel pushing occasionally to verify rendering is happening
-		if p.Pfc.PushedX%80 == 0 && currentLine%32 == 0 {
-			logger.Debug("PPU: Pushed pixel %d on line %d (FIFO size: %d)", p.Pfc.PushedX, currentLine, p.Pfc.PixelFifo.size)
-		}
-	} else {
-		// Debug: Log when FIFO is too small
-		if LcdCtx().Ly < 10 && p.LineTicks%100 == 0 {
-			logger.Debug("PPU: FIFO too small (size=%d), not pushing pixels", p.Pfc.PixelFifo.size)
-		}
+	if p.Pfc.WinDiscard > 0 {
+		// WX < 7: the window's leftmost pixels are off screen
+		p.Pfc.WinDiscard--
+		return
 	}
-}
 
-// SpritePixel represents a sprite pixel with priority information
-type SpritePixel struct {
-	Color    uint32
-	Priority bool // true if sprite has priority over background (FBgp = 0)
-	Present  bool // true if sprite pixel is present (not transparent)
-}
-
-// GetSpritePixel checks if there's a sprite pixel at the given position
-func (p *PpuContext) GetSpritePixel(x uint8, y uint8) SpritePixel {
-	// Check all sprites on this line
-	sprite := p.LineSprites
-	for sprite != nil {
-		entry := sprite.Entry
-
-		// Check if this sprite covers the current pixel
-		if entry.X <= x+8 && entry.X > x {
-			// Calculate pixel position within sprite
-			spriteX := x + 8 - entry.X
-			spriteY := y + 16 - entry.Y
-
-			// Get sprite height
-			spriteHeight := LCDCObjHeight()
-
-			// Bounds check
-			if spriteX < 8 && spriteY < spriteHeight {
-				// Get sprite tile data
-				tileNum := entry.Tile
-
-				// For 8x16 sprites, mask out the lower bit
-				if spriteHeight == 16 {
-					tileNum &= 0xFE
-				}
-
-				// Handle vertical flip
-				if entry.FYFlip != 0 {
-					spriteY = spriteHeight - 1 - spriteY
-				}
-
-				// Handle horizontal flip
-				if entry.FXFlip != 0 {
-					spriteX = 7 - spriteX
-				}
-
-				// Calculate tile data address (sprites always use 0x8000 method)
-				tileDataAddr := 0x8000 + uint16(tileNum)*16 + uint16(spriteY)*2
-
-				// Get the two bytes that define this row of the sprite
-				byte1 := p.VramRead(tileDataAddr)
-				byte2 := p.VramRead(tileDataAddr + 1)
-
-				// Extract the pixel from the sprite data
-				bitPosition := 7 - spriteX
-				bit0 := (byte1 >> bitPosition) & 1
-				bit1 := (byte2 >> bitPosition) & 1
-				colorIndex := (bit1 << 1) | bit0
-
-				// Color index 0 is transparent for sprites
-				if colorIndex != 0 {
-					// Get sprite palette
-					var paletteColors [4]uint32
-					if entry.FPn != 0 {
-						paletteColors = LcdCtx().Sp2Colors
-					} else {
-						paletteColors = LcdCtx().Sp1Colors
-					}
-
-					return SpritePixel{
-						Color:    uint32(paletteColors[colorIndex]),
-						Priority: entry.FBgp == 0, // Priority when FBgp = 0
-						Present:  true,
-					}
-				}
-			}
+	objPixel := p.ObjFifoPop()
+	color := bgPixel.Color
+	if LCDCObjEnable() && objPixel.ColorIndex != 0 && (!objPixel.BgPriority || bgPixel.IsBgColor0) {
+		if objPixel.Palette != 0 {
+			color = LcdCtx().Sp2Colors[objPixel.ColorIndex]
+		} else {
+			color = LcdCtx().Sp1Colors[objPixel.ColorIndex]
 		}
+	}
 
-		sprite = sprite.Next
+	currentLine := LcdCtx().Ly
+	if currentLine < YRES && p.Pfc.PushedX < XRES && !p.BlankFrame {
+		p.VideoBuffer[uint32(currentLine)*XRES+uint32(p.Pfc.PushedX)] = color
 	}
 
-	return SpritePixel{Color: 0, Priority: false, Present: false} // No sprite pixel (transparent)
+	p.Pfc.PushedX++
+	p.Pfc.LineX++
 }
 
 // RenderLine renders one complete scanline of background tiles
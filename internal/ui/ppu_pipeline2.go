@@ -30,49 +30,153 @@ func (p *PpuContext) PipelineReset() {
 	logger.Debug("Pipeline reset for line %d", LcdCtx().Ly)
 }
 
-func (p *PpuContext) PipelineLoadWindowTile() {
-	// Window tile loading - similar to background but using window coordinates
-	if !LCDCWinEnable() {
-		logger.Debug("Window disabled, skipping window tile load")
-		return
+// windowStartsHere reports whether the next pixel out of the FIFO is the
+// first window pixel on this line. WX, WY and LCDC.5 are read live so mid-line
+// and mid-frame changes take effect on the next pixel.
+func (p *PpuContext) windowStartsHere() bool {
+	lcd := LcdCtx()
+	if p.Pfc.WindowActive || !p.WinYTriggered || !LCDCWinEnable() || lcd.WinX > 166 {
+		return false
 	}
 
-	// Check if window should be visible at current position
-	winX := LcdCtx().WinX
-	winY := LcdCtx().WinY
-	currentX := p.Pfc.FetchX
-	currentY := LcdCtx().Ly
+	// Finish discarding the SCX fine scroll pixels first, and only switch
+	// once a background pixel is ready to go out
+	if p.Pfc.LineX < lcd.ScrollX%8 {
+		return false
+	}
+	if p.Pfc.PixelFifo.size == 0 && p.Pfc.CurFetchState != FS_PUSH {
+		return false
+	}
 
-	// Window is visible if current position is within window bounds
-	if currentY >= winY && currentX >= winX-7 {
-		// Calculate window-relative coordinates
-		windowTileY := int(p.WindowLine) / 8
-		windowTileX := int(currentX-(winX-7)) / 8
+	startX := int(lcd.WinX) - 7
+	if startX < 0 {
+		startX = 0
+	}
+	return int(p.Pfc.PushedX) == startX
+}
 
-		// Get window map address
-		mapAddr := LCDCWinMapArea() + uint16(windowTileY*32+windowTileX)
+// PipelineStartWindow clears the background FIFO and restarts the fetcher at
+// the first window tile
+func (p *PpuContext) PipelineStartWindow() {
+	p.PipelineFifoReset()
+	p.Pfc.CurFetchState = FS_TILE
+	p.Pfc.FetchX = p.Pfc.LineX
+	p.Pfc.WindowActive = true
+	p.Pfc.WindowDrawn = true
+	p.Pfc.WinTileX = 0
+	p.Pfc.WinDiscard = 0
+
+	if winX := LcdCtx().WinX; winX < 7 {
+		p.Pfc.WinDiscard = 7 - winX
+	}
 
-		// Read tile number from window map
-		tileNum := p.VramRead(mapAddr)
+	logger.Debug("PPU: Window started at x=%d on line %d (window line %d)", p.Pfc.PushedX, LcdCtx().Ly, p.WindowLine)
+}
 
-		// Handle signed tile numbers for window (same as background)
-		if LCDCBGWDataArea() == 0x8800 {
-			tileNum += 128
-		}
+// PipelineLoadWindowTile fetches the next tile number from the window map.
+// The map row comes from the internal window line counter rather than LY-WY,
+// so lines where the window was hidden don't skip window rows.
+func (p *PpuContext) PipelineLoadWindowTile() {
+	tileY := uint16(p.WindowLine / 8)
+	tileX := uint16(p.Pfc.WinTileX % 32)
+
+	p.Pfc.BgwFetchData[0] = p.VramRead(LCDCWinMapArea() + tileY*32 + tileX)
+	p.Pfc.TileY = (p.WindowLine % 8) * 2
+	p.Pfc.WinTileX++
+}
 
-		// Store in fetch data
-		p.Pfc.BgwFetchData[0] = tileNum
+// spriteDue reports whether the next sprite on the line starts at the pixel
+// about to be shifted out. Sprites with X < 8 are fetched at the left edge.
+func (p *PpuContext) spriteDue() bool {
+	sprite := p.Pfc.NextSprite
+	if sprite == nil || !LCDCObjEnable() {
+		return false
+	}
+	if p.Pfc.LineX < LcdCtx().ScrollX%8 || p.Pfc.WinDiscard > 0 {
+		return false
+	}
+	return int(sprite.Entry.X)-8 <= int(p.Pfc.PushedX)
+}
 
-		logger.Debug("PPU: Window tile loaded - tileNum=0x%02X at (%d,%d)", tileNum, windowTileX, windowTileY)
+// PipelineSpriteFetchStep advances the six dot sprite fetch: tile number,
+// then the low and high bytes of the sprite row
+func (p *PpuContext) PipelineSpriteFetchStep() {
+	p.Pfc.SpriteFetchDots++
+	switch p.Pfc.SpriteFetchDots {
+	case 2:
+		p.PipelineLoadSpriteTile()
+	case 4:
+		p.PipelineLoadSpriteData(0)
+	case 6:
+		p.PipelineLoadSpriteData(1)
+		p.PipelineMergeSprite()
+		p.Pfc.NextSprite = p.Pfc.NextSprite.Next
+		p.Pfc.SpriteFetching = false
 	}
 }
 
+// PipelineLoadSpriteTile works out the tile data address of the sprite row
+// on the current line
 func (p *PpuContext) PipelineLoadSpriteTile() {
-	// TODO: Implement sprite tile loading
-	logger.Debug("Sprite tile loading not yet implemented")
+	entry := p.Pfc.NextSprite.Entry
+	height := LCDCObjHeight()
+
+	tileNum := entry.Tile
+	if height == 16 {
+		tileNum &= 0xFE
+	}
+
+	row := (LcdCtx().Ly + 16 - entry.Y) % height
+	if entry.FYFlip != 0 {
+		row = height - 1 - row
+	}
+
+	// Sprites always use the $8000 addressing mode
+	p.Pfc.SpriteAddr = 0x8000 + uint16(tileNum)*16 + uint16(row)*2
 }
 
+// PipelineLoadSpriteData reads the low (offset 0) or high (offset 1) byte of
+// the sprite row
 func (p *PpuContext) PipelineLoadSpriteData(offset int) {
-	// TODO: Implement sprite data loading
-	logger.Debug("Sprite data loading not yet implemented for offset %d", offset)
+	p.Pfc.FetchEntryData[offset] = p.VramRead(p.Pfc.SpriteAddr + uint16(offset))
+}
+
+// PipelineMergeSprite mixes the fetched sprite row into the object FIFO.
+// Pixels already holding an opaque sprite pixel are kept, so sprites fetched
+// earlier win.
+func (p *PpuContext) PipelineMergeSprite() {
+	entry := p.Pfc.NextSprite.Entry
+	lo, hi := p.Pfc.FetchEntryData[0], p.Pfc.FetchEntryData[1]
+
+	// Pixels of the sprite left of the current position are cut off
+	skip := int(p.Pfc.PushedX) - (int(entry.X) - 8)
+	if skip < 0 {
+		skip = 0
+	}
+
+	for i := skip; i < 8; i++ {
+		bit := 7 - i
+		if entry.FXFlip != 0 {
+			bit = i
+		}
+		colorIndex := (lo>>bit)&1 | ((hi>>bit)&1)<<1
+
+		slot := &p.Pfc.ObjFifo[(int(p.Pfc.ObjHead)+i-skip)%8]
+		if slot.ColorIndex == 0 && colorIndex != 0 {
+			*slot = ObjPixel{
+				ColorIndex: colorIndex,
+				Palette:    uint8(entry.FPn),
+				BgPriority: entry.FBgp != 0,
+			}
+		}
+	}
+}
+
+// ObjFifoPop shifts one pixel out of the object FIFO. Empty slots are
+// transparent.
+func (p *PpuContext) ObjFifoPop() ObjPixel {
+	px := p.Pfc.ObjFifo[p.Pfc.ObjHead]
+	p.Pfc.ObjFifo[p.Pfc.ObjHead] = ObjPixel{}
+	p.Pfc.ObjHead = (p.Pfc.ObjHead + 1) % 8
+	return px
 }
@@ -1,28 +1,15 @@
 package ui
 
 import (
-	"app/internal/cpu"
 	logger "app/internal/logger"
 )
 
 func (p *PpuContext) IncrementLY() {
-	currentLy := LcdCtx().Ly
-	windowEnabled := LCDCWinEnable()
-	windowOnScreen := LcdCtx().WinX < 167
-
-	switch {
-	case !windowEnabled || !windowOnScreen:
-		p.WindowLine = 0
-	case currentLy < LcdCtx().WinY:
-		p.WindowLine = 0
-	case currentLy < YRES:
-		if currentLy == LcdCtx().WinY && p.WindowLine != 0 {
-			p.WindowLine = 0
-		}
+	// The window line counter only advances on lines where the window was
+	// actually drawn, so hiding it mid-frame resumes at the next window row
+	if p.Pfc.WindowDrawn {
 		p.WindowLine++
-		if p.WindowLine <= 20 {
-			logger.Debug("PPU: Window line advanced to %d after scanline %d", p.WindowLine, currentLy)
-		}
+		p.Pfc.WindowDrawn = false
 	}
 
 	lcdCtx := LcdCtx()
@@ -32,84 +19,39 @@ func (p *PpuContext) IncrementLY() {
 		logger.Debug("PPU: LY incremented to %d, LYC=%d, LCDC=0x%02X", lcdCtx.Ly, lcdCtx.LyCompare, lcdCtx.Lcdc)
 	}
 
-	if lcdCtx.Ly == lcdCtx.LyCompare {
-		LCDSLycSet(true)
-
-		logger.Debug("PPU: LY=LYC match! LY=%d, LYC=%d, STAT=0x%02X", lcdCtx.Ly, lcdCtx.LyCompare, lcdCtx.Lcds)
-
-		if LCDSStatInt(SSLyc) {
-			// Request LCD STAT interrupt (like reference)
-			cpu.CpuRequestInterrupt(cpu.IT_LCD_STAT)
-			logger.Debug("PPU: *** LY=LYC INTERRUPT REQUESTED *** (LY=%d)", lcdCtx.Ly)
-		} else {
-			logger.Debug("PPU: LY=LYC match but STAT interrupt not enabled")
-		}
-	} else {
-		LCDSLycSet(false)
-	}
+	CompareLY()
 }
 
+// LoadLineSprites runs the OAM scan: the first 10 sprites in OAM order whose
+// rows cover the current line are selected, whatever their X. Sprites at
+// X=0 or X>=168 are invisible but still use up a slot. The selection is kept
+// sorted by X, ties staying in OAM order, which is the DMG drawing priority.
 func (p *PpuContext) LoadLineSprites() {
 	curY := LcdCtx().Ly
 	spriteHeight := LCDCObjHeight()
 
-	// Reset LineEntryArray and LineSpriteCount
-	for i := range p.LineEntryArray {
-		p.LineEntryArray[i] = OamLineEntry{}
-	}
 	p.LineSpriteCount = 0
 	p.LineSprites = nil
 
-	for i := 0; i < 40; i++ {
+	for i := 0; i < len(p.OamRam) && p.LineSpriteCount < uint(len(p.LineEntryArray)); i++ {
 		e := p.OamRam[i]
 
-		if e.X == 0 {
-			// x = 0 means not visible...
+		// Y is the sprite's bottom edge + 16, the top line is Y-16
+		row := int(curY) + 16 - int(e.Y)
+		if row < 0 || row >= int(spriteHeight) {
 			continue
 		}
 
-		if p.LineSpriteCount >= 10 {
-			// max 10 sprites per line...
-			break
-		}
-
-		if e.Y <= curY+16 && e.Y+uint8(spriteHeight) > curY+16 {
-			// this sprite is on the current line.
-			entry := &p.LineEntryArray[p.LineSpriteCount]
-			p.LineSpriteCount++
-
-			entry.Entry = e
-			entry.Next = nil
-
-			if p.LineSprites == nil || p.LineSprites.Entry.X > e.X {
-				entry.Next = p.LineSprites
-				p.LineSprites = entry
-				continue
-			}
-
-			// Insert sprite in sorted order based on X position
-			le := p.LineSprites
-			var prev *OamLineEntry
-
-			for le != nil {
-				if le.Entry.X > e.X {
-					if prev != nil {
-						prev.Next = entry
-					} else {
-						p.LineSprites = entry
-					}
-					entry.Next = le
-					break
-				}
-
-				if le.Next == nil {
-					le.Next = entry
-					break
-				}
+		entry := &p.LineEntryArray[p.LineSpriteCount]
+		p.LineSpriteCount++
+		entry.Entry = e
 
-				prev = le
-				le = le.Next
-			}
+		// Insert after every sprite with the same or a lower X
+		link := &p.LineSprites
+		for *link != nil && (*link).Entry.X <= e.X {
+			link = &(*link).Next
 		}
+		entry.Next = *link
+		*link = entry
 	}
 }
@@ -0,0 +1,555 @@
+package ui
+
+import (
+	"app/internal/cpu"
+	"app/internal/model"
+	"testing"
+)
+
+const (
+	testWhite = 0xFFFFFFFF
+	testGray  = 0xFFAAAAAA
+	testBlack = 0xFF000000
+)
+
+// newTestPpu resets the PPU and LCD to line 0 with the LCD, background and
+// window on, tile data at $8000, the background map at $9800 and the window
+// map at $9C00. Tile 0 is white, tile 1 black and tile 2 light gray.
+func newTestPpu() *PpuContext {
+	ppuInstance = NewPpuContext()
+	LcdInit()
+
+	lcd := LcdCtx()
+	lcd.Lcdc = LCDC_DISPLAY_ENABLE | LCDC_WIN_MAP | LCDC_WIN_ENABLE | LCDC_TILE_DATA | LCDC_BG_ENABLE
+	lcd.Ly = 0
+	lcd.BgPalette = 0xE4
+	UpdatePalette(0xE4, 0)
+	SetLCDMode(ModeOam)
+
+	p := ppuInstance
+	for row := 0; row < 8; row++ {
+		p.Vram[16+row*2], p.Vram[16+row*2+1] = 0xFF, 0xFF
+		p.Vram[32+row*2] = 0xFF
+	}
+	return p
+}
+
+// fillMap sets every entry of the 32x32 tile map at base to tile
+func fillMap(p *PpuContext, base uint16, tile byte) {
+	for i := uint16(0); i < 32*32; i++ {
+		p.Vram[base-0x8000+i] = tile
+	}
+}
+
+// runTestFrame runs one frame from line 0, calling hook before every dot
+func runTestFrame(p *PpuContext, hook func()) {
+	for i := 0; i < LINES_PER_FRAME*TICKS_PER_LINE; i++ {
+		if hook != nil {
+			hook()
+		}
+		p.PpuTick()
+	}
+}
+
+func pixel(p *PpuContext, x, y int) uint32 {
+	return p.VideoBuffer[y*XRES+x]
+}
+
+func TestWindowLineCounterSkipsHiddenLines(t *testing.T) {
+	p := newTestPpu()
+	fillMap(p, 0x9800, 0)
+	fillMap(p, 0x9C00, 2)
+	for x := uint16(0); x < 32; x++ {
+		p.Vram[0x9C00-0x8000+x] = 1 // Window row 0 is black, later rows gray
+	}
+	LcdCtx().WinX = 7
+
+	runTestFrame(p, func() {
+		if p.LineTicks != 0 {
+			return
+		}
+		// Hide the window on lines 4-19 by moving it off screen
+		switch LcdCtx().Ly {
+		case 4:
+			LcdCtx().WinX = 200
+		case 20:
+			LcdCtx().WinX = 7
+		}
+	})
+
+	for _, tc := range []struct {
+		y    int
+		want uint32
+	}{
+		{0, testBlack},
+		{3, testBlack},
+		{4, testWhite},
+		{19, testWhite},
+		{20, testBlack}, // Window line 4, still in window row 0
+		{23, testBlack},
+		{24, testGray}, // Window line 8
+	} {
+		if got := pixel(p, 40, tc.y); got != tc.want {
+			t.Errorf("line %d: pixel = %08X, want %08X", tc.y, got, tc.want)
+		}
+	}
+}
+
+func TestWindowMidFrameWY(t *testing.T) {
+	p := newTestPpu()
+	fillMap(p, 0x9800, 0)
+	fillMap(p, 0x9C00, 1)
+	LcdCtx().WinX = 7
+	LcdCtx().WinY = 50
+
+	runTestFrame(p, func() {
+		if p.LineTicks == 0 && LcdCtx().Ly == 10 {
+			LcdCtx().WinY = 60
+		}
+	})
+
+	if got := pixel(p, 0, 55); got != testWhite {
+		t.Errorf("line 55: pixel = %08X, want white", got)
+	}
+	if got := pixel(p, 0, 60); got != testBlack {
+		t.Errorf("line 60: pixel = %08X, want black", got)
+	}
+
+	// Moving WY above the current line doesn't trigger the window
+	p = newTestPpu()
+	fillMap(p, 0x9800, 0)
+	fillMap(p, 0x9C00, 1)
+	LcdCtx().WinX = 7
+	LcdCtx().WinY = 50
+
+	runTestFrame(p, func() {
+		if p.LineTicks == 0 && LcdCtx().Ly == 30 {
+			LcdCtx().WinY = 20
+		}
+	})
+
+	for _, y := range []int{20, 30, 100} {
+		if got := pixel(p, 0, y); got != testWhite {
+			t.Errorf("line %d: pixel = %08X, want white", y, got)
+		}
+	}
+}
+
+func TestWindowWXEdges(t *testing.T) {
+	// WX < 7 starts the window at the left edge with its first 7-WX pixels cut off
+	p := newTestPpu()
+	fillMap(p, 0x9800, 1)
+	fillMap(p, 0x9C00, 0)
+	for row := 0; row < 8; row++ {
+		p.Vram[48+row*2], p.Vram[48+row*2+1] = 0xF0, 0xF0 // Tile 3: left half black
+	}
+	for y := uint16(0); y < 32; y++ {
+		p.Vram[0x9C00-0x8000+y*32] = 3
+	}
+	LcdCtx().WinX = 3
+
+	runTestFrame(p, nil)
+
+	for x := 0; x < XRES; x++ {
+		if got := pixel(p, x, 0); got != testWhite {
+			t.Fatalf("WX=3: pixel %d = %08X, want white", x, got)
+		}
+	}
+
+	// WX = 166 only covers the last pixel
+	p = newTestPpu()
+	fillMap(p, 0x9800, 0)
+	fillMap(p, 0x9C00, 1)
+	LcdCtx().WinX = 166
+
+	runTestFrame(p, nil)
+
+	if got := pixel(p, 158, 0); got != testWhite {
+		t.Errorf("WX=166: pixel 158 = %08X, want white", got)
+	}
+	if got := pixel(p, 159, 0); got != testBlack {
+		t.Errorf("WX=166: pixel 159 = %08X, want black", got)
+	}
+}
+
+func TestWindowDisabledMidLine(t *testing.T) {
+	p := newTestPpu()
+	fillMap(p, 0x9800, 0)
+	fillMap(p, 0x9C00, 1)
+	LcdCtx().WinX = 7
+
+	runTestFrame(p, func() {
+		if LcdCtx().Ly == 0 && LCDSMode() == ModeXfer && p.Pfc.PushedX >= 80 {
+			LcdCtx().Lcdc &^= LCDC_WIN_ENABLE
+		}
+	})
+
+	if got := pixel(p, 10, 0); got != testBlack {
+		t.Errorf("pixel 10 = %08X, want black", got)
+	}
+	if got := pixel(p, 150, 0); got != testWhite {
+		t.Errorf("pixel 150 = %08X, want white", got)
+	}
+	if got := pixel(p, 10, 1); got != testWhite {
+		t.Errorf("line 1 pixel 10 = %08X, want white", got)
+	}
+}
+
+// mode3Length runs line 0 and returns the number of dots spent in mode 3
+func mode3Length(p *PpuContext) int {
+	start := -1
+	for i := 0; i < TICKS_PER_LINE; i++ {
+		p.PpuTick()
+		switch {
+		case start < 0 && LCDSMode() == ModeXfer:
+			start = int(p.LineTicks)
+		case start >= 0 && LCDSMode() == ModeHBlank:
+			return int(p.LineTicks) - start
+		}
+	}
+	return -1
+}
+
+func TestMode3Length(t *testing.T) {
+	tests := []struct {
+		name    string
+		scx     uint8
+		window  bool
+		sprites []OamEntry
+		want    int
+	}{
+		{name: "plain", want: 172},
+		{name: "scx fine scroll", scx: 3, want: 175},
+		{name: "window", window: true, want: 178},
+		{name: "sprite at x=0", sprites: []OamEntry{{Y: 16, X: 8, Tile: 1}}, want: 183},
+		{name: "sprite at x=5", sprites: []OamEntry{{Y: 16, X: 13, Tile: 1}}, want: 178},
+		{name: "sprite off the left edge", sprites: []OamEntry{{Y: 16, X: 1, Tile: 1}}, want: 183},
+		{name: "sprite off the right edge", sprites: []OamEntry{{Y: 16, X: 168, Tile: 1}}, want: 172},
+		{name: "two sprites same x", sprites: []OamEntry{{Y: 16, X: 80, Tile: 1}, {Y: 16, X: 80, Tile: 1}}, want: 189},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestPpu()
+			LcdCtx().Lcdc |= LCDC_OBJ_ENABLE
+			LcdCtx().ScrollX = tc.scx
+			if tc.window {
+				LcdCtx().WinX = 7
+			} else {
+				LcdCtx().WinX = 200
+			}
+			copy(p.OamRam[:], tc.sprites)
+
+			if got := mode3Length(p); got != tc.want {
+				t.Errorf("mode 3 length = %d dots, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSpriteRendering(t *testing.T) {
+	p := newTestPpu()
+	LcdCtx().Lcdc |= LCDC_OBJ_ENABLE
+	LcdCtx().WinX = 200
+	UpdatePalette(0xE4, 1)
+	UpdatePalette(0xE4, 2)
+	fillMap(p, 0x9800, 0)
+	fillMap(p, 0x9C00, 0)
+	p.Vram[0x9800-0x8000+8] = 2 // Gray background under half of sprite 2
+	for row := 0; row < 8; row++ {
+		p.Vram[48+row*2], p.Vram[48+row*2+1] = 0xF0, 0xF0 // Tile 3: left half black
+	}
+
+	p.OamRam[0] = OamEntry{Y: 16, X: 16, Tile: 3}
+	p.OamRam[1] = OamEntry{Y: 16, X: 24, Tile: 3, FXFlip: 1}
+	p.OamRam[2] = OamEntry{Y: 16, X: 76, Tile: 1, FBgp: 1}
+	p.OamRam[3] = OamEntry{Y: 16, X: 4, Tile: 1}
+
+	runTestFrame(p, nil)
+
+	for _, tc := range []struct {
+		x    int
+		want uint32
+	}{
+		{0, testBlack}, // Sprite 3, half off the left edge
+		{3, testBlack},
+		{4, testWhite},
+		{8, testBlack}, // Sprite 0
+		{12, testWhite},
+		{16, testWhite}, // Sprite 1, flipped
+		{20, testBlack},
+		{68, testGray}, // Sprite 2 behind background colors 1-3
+		{71, testGray},
+		{72, testBlack}, // Sprite 2 over background color 0
+		{75, testBlack},
+		{76, testWhite},
+	} {
+		if got := pixel(p, tc.x, 0); got != tc.want {
+			t.Errorf("pixel %d = %08X, want %08X", tc.x, got, tc.want)
+		}
+	}
+}
+
+// statInterruptsPerLine runs one frame and counts the STAT interrupts
+// requested on each line
+func statInterruptsPerLine(p *PpuContext) [LINES_PER_FRAME]int {
+	var counts [LINES_PER_FRAME]int
+	cpu.CpuSetIntFlags(0)
+	for i := 0; i < LINES_PER_FRAME*TICKS_PER_LINE; i++ {
+		ly := LcdCtx().Ly
+		p.PpuTick()
+		if cpu.CpuGetIntFlags()&byte(cpu.IT_LCD_STAT) != 0 {
+			counts[ly]++
+			cpu.CpuSetIntFlags(0)
+		}
+	}
+	return counts
+}
+
+func TestStatInterruptSources(t *testing.T) {
+	cpu.NewCpuContext(nil)
+
+	p := newTestPpu()
+	LcdWrite(0xFF41, byte(SSHBlank))
+	counts := statInterruptsPerLine(p)
+	for ly := 0; ly < YRES; ly++ {
+		if counts[ly] != 1 {
+			t.Fatalf("H-blank source: line %d raised %d interrupts, want 1", ly, counts[ly])
+		}
+	}
+	if counts[YRES] != 0 {
+		t.Errorf("H-blank source: V-blank raised %d interrupts", counts[YRES])
+	}
+
+	p = newTestPpu()
+	LcdWrite(0xFF41, byte(SSVBlank))
+	counts = statInterruptsPerLine(p)
+	// LY is already 144 when V-blank starts at the end of line 143
+	if counts[YRES-1] != 1 {
+		t.Errorf("V-blank source: %d interrupts on entering V-blank, want 1", counts[YRES-1])
+	}
+
+	p = newTestPpu()
+	LcdWrite(0xFF41, byte(SSOam))
+	counts = statInterruptsPerLine(p)
+	// Each line's mode 2 starts on the last dot of the line before, and the
+	// source fires once more when line 144 starts
+	for ly := 0; ly < YRES; ly++ {
+		if counts[ly] != 1 {
+			t.Fatalf("OAM source: line %d raised %d interrupts, want 1", ly, counts[ly])
+		}
+	}
+	if counts[LINES_PER_FRAME-1] != 1 {
+		t.Errorf("OAM source: line 153 raised %d interrupts, want 1 for line 0", counts[LINES_PER_FRAME-1])
+	}
+}
+
+func TestStatLineBlocking(t *testing.T) {
+	cpu.NewCpuContext(nil)
+
+	// LY=LYC on line 5 keeps the line high from the H-blank of line 4 to
+	// the end of line 5, so only the first rising edge is seen
+	p := newTestPpu()
+	LcdWrite(0xFF45, 5)
+	LcdWrite(0xFF41, byte(SSHBlank|SSLyc))
+	counts := statInterruptsPerLine(p)
+
+	if counts[3] != 1 || counts[4] != 1 {
+		t.Errorf("lines 3-4: %d, %d interrupts, want 1, 1", counts[3], counts[4])
+	}
+	if counts[5] != 0 {
+		t.Errorf("line 5: %d interrupts, want 0 (blocked by the STAT line)", counts[5])
+	}
+	if counts[6] != 1 {
+		t.Errorf("line 6: %d interrupts, want 1", counts[6])
+	}
+}
+
+func TestStatWrite(t *testing.T) {
+	cpu.NewCpuContext(nil)
+	newTestPpu()
+
+	LcdCtx().Ly = 144
+	SetLCDMode(ModeVBlank)
+	cpu.CpuSetIntFlags(0)
+
+	// The DMG write bug raises an interrupt in V-blank even with no
+	// sources enabled
+	LcdWrite(0xFF41, 0x00)
+	if cpu.CpuGetIntFlags()&byte(cpu.IT_LCD_STAT) == 0 {
+		t.Error("STAT write in V-blank did not raise an interrupt")
+	}
+
+	LcdWrite(0xFF41, 0xFF)
+	if got := LcdRead(0xFF41); got != 0xFD {
+		t.Errorf("STAT = %02X, want FD (mode and LYC flag read-only, bit 7 set)", got)
+	}
+}
+
+func TestLcdOffOn(t *testing.T) {
+	cpu.NewCpuContext(nil)
+	p := newTestPpu()
+	fillMap(p, 0x9800, 1)
+	lcdc := LcdCtx().Lcdc
+	LcdCtx().WinX = 200
+
+	// Switch off mid-frame, in the middle of line 50
+	for LcdCtx().Ly != 50 || p.LineTicks != 200 {
+		p.PpuTick()
+	}
+	LcdWrite(0xFF40, lcdc&^LCDC_DISPLAY_ENABLE)
+
+	if ly := LcdRead(0xFF44); ly != 0 {
+		t.Errorf("LY = %d after LCD off, want 0", ly)
+	}
+	if LCDSMode() != ModeHBlank {
+		t.Errorf("STAT mode = %d after LCD off, want 0", LCDSMode())
+	}
+	if got := pixel(p, 0, 10); got != testWhite {
+		t.Errorf("pixel = %08X after LCD off, want white", got)
+	}
+
+	for i := 0; i < 2*TICKS_PER_LINE; i++ {
+		p.PpuTick()
+	}
+	if LcdCtx().Ly != 0 || p.LineTicks != 0 {
+		t.Errorf("PPU advanced to LY=%d dot %d while off", LcdCtx().Ly, p.LineTicks)
+	}
+
+	// Switch back on: line 0 is 4 dots short and starts in mode 0
+	LcdWrite(0xFF40, lcdc)
+	dots := 0
+	for LcdCtx().Ly == 0 {
+		if dots == 10 && LCDSMode() != ModeHBlank {
+			t.Errorf("STAT mode = %d early on the first line, want 0", LCDSMode())
+		}
+		p.PpuTick()
+		dots++
+	}
+	if dots != TICKS_PER_LINE-4 {
+		t.Errorf("first line after LCD on took %d dots, want %d", dots, TICKS_PER_LINE-4)
+	}
+
+	// The first frame is not shown, the second one is
+	for LcdCtx().Ly != YRES {
+		p.PpuTick()
+	}
+	if got := pixel(p, 0, 100); got != testWhite {
+		t.Errorf("first frame after LCD on: pixel = %08X, want white", got)
+	}
+	runTestFrame(p, nil)
+	if got := pixel(p, 0, 100); got != testBlack {
+		t.Errorf("second frame after LCD on: pixel = %08X, want black", got)
+	}
+}
+
+func TestSpriteSelectionAndPriority(t *testing.T) {
+	p := newTestPpu()
+	LcdCtx().Lcdc |= LCDC_OBJ_ENABLE
+	LcdCtx().WinX = 200
+	UpdatePalette(0xE4, 1) // OBP0: colors as numbered
+	UpdatePalette(0x00, 2) // OBP1: everything white
+	fillMap(p, 0x9800, 2)  // Gray background
+
+	// An X=0 sprite uses one of the 10 slots, so the sprite at OAM 10 is dropped
+	p.OamRam[0] = OamEntry{Y: 16, X: 0, Tile: 1}
+	for i := 1; i < 10; i++ {
+		p.OamRam[i] = OamEntry{Y: 16, X: 168, Tile: 1}
+	}
+	p.OamRam[10] = OamEntry{Y: 16, X: 16, Tile: 1}
+
+	// Line 8: lower X wins over lower OAM index, and on a tie the lower OAM
+	// index wins. Sprites using OBP1 are white, OBP0 black.
+	p.OamRam[11] = OamEntry{Y: 24, X: 44, Tile: 1, FPn: 1}
+	p.OamRam[12] = OamEntry{Y: 24, X: 40, Tile: 1}
+	p.OamRam[13] = OamEntry{Y: 24, X: 72, Tile: 1, FPn: 1}
+	p.OamRam[14] = OamEntry{Y: 24, X: 72, Tile: 1}
+
+	runTestFrame(p, nil)
+
+	if got := pixel(p, 8, 0); got != testGray {
+		t.Errorf("11th sprite on the line was drawn: pixel = %08X, want gray", got)
+	}
+
+	for _, tc := range []struct {
+		x    int
+		want uint32
+	}{
+		{32, testBlack}, // OAM 12 alone
+		{36, testBlack}, // OAM 12 has the lower X where both overlap
+		{40, testWhite}, // OAM 11 after OAM 12 ends
+		{64, testWhite}, // OAM 13 beats OAM 14 at the same X
+	} {
+		if got := pixel(p, tc.x, 8); got != tc.want {
+			t.Errorf("line 8 pixel %d = %08X, want %08X", tc.x, got, tc.want)
+		}
+	}
+}
+
+func TestTallSpriteTileMasking(t *testing.T) {
+	p := newTestPpu()
+	LcdCtx().Lcdc |= LCDC_OBJ_ENABLE | LCDC_OBJ_SIZE
+	LcdCtx().WinX = 200
+	UpdatePalette(0xE4, 1)
+	fillMap(p, 0x9800, 0)
+
+	// Tile 3 is ignored: the top half comes from tile 2 and the bottom from 3
+	p.OamRam[0] = OamEntry{Y: 16, X: 8, Tile: 3}
+	for row := 0; row < 8; row++ {
+		p.Vram[48+row*2], p.Vram[48+row*2+1] = 0xFF, 0xFF
+	}
+
+	runTestFrame(p, nil)
+
+	if got := pixel(p, 0, 0); got != testGray {
+		t.Errorf("top half: pixel = %08X, want gray (tile 2)", got)
+	}
+	if got := pixel(p, 0, 8); got != testBlack {
+		t.Errorf("bottom half: pixel = %08X, want black (tile 3)", got)
+	}
+	if got := pixel(p, 0, 16); got != testWhite {
+		t.Errorf("below the sprite: pixel = %08X, want white", got)
+	}
+}
+
+func TestCpuAccessBlocking(t *testing.T) {
+	p := newTestPpu()
+	tests := []struct {
+		mode      lcdMode
+		vram, oam bool
+	}{
+		{ModeHBlank, false, false},
+		{ModeVBlank, false, false},
+		{ModeOam, false, true},
+		{ModeXfer, true, true},
+	}
+	for _, tt := range tests {
+		SetLCDMode(tt.mode)
+		if p.VramBlocked() != tt.vram || p.OamBlocked() != tt.oam {
+			t.Errorf("mode %d: VRAM blocked %v, OAM blocked %v; want %v, %v", tt.mode, p.VramBlocked(), p.OamBlocked(), tt.vram, tt.oam)
+		}
+	}
+
+	// Nothing is blocked with the LCD off
+	SetLCDMode(ModeXfer)
+	LcdCtx().Lcdc &^= LCDC_DISPLAY_ENABLE
+	if p.VramBlocked() || p.OamBlocked() {
+		t.Error("access blocked with the LCD off")
+	}
+}
+
+func TestStatWriteNoBugOnCgb(t *testing.T) {
+	model.Set(model.CGB)
+	defer model.Set(model.DMG)
+	cpu.NewCpuContext(nil)
+	newTestPpu()
+
+	LcdCtx().Ly = 144
+	SetLCDMode(ModeVBlank)
+	cpu.CpuSetIntFlags(0)
+
+	LcdWrite(0xFF41, 0x00)
+	if cpu.CpuGetIntFlags()&byte(cpu.IT_LCD_STAT) != 0 {
+		t.Error("STAT write raised an interrupt on the CGB")
+	}
+}
@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// SpeedMode selects how fast emulated time runs relative to real time
+type SpeedMode int
+
+const (
+	SpeedNormal      SpeedMode = iota // 1x, paced to the DMG refresh rate
+	SpeedFastForward                  // Several frames per tick, intermediate frames are skipped
+	SpeedSlowMotion                   // Fewer than one frame per tick
+	SpeedUncapped                     // As many frames as fit in a tick (benchmarks)
+)
+
+const (
+	DefaultFastForwardSpeed = 4.0
+	MaxFastForwardSpeed     = 16.0
+	MinSlowMotionSpeed      = 0.25
+
+	// uncappedTickBudget is how long a single UI tick may spend emulating in
+	// uncapped mode before handing control back to ebiten for drawing
+	uncappedTickBudget = 15 * time.Millisecond
+)
+
+// slowMotionSteps are cycled through by the slow-motion hotkey
+var slowMotionSteps = []float64{1.0, 0.75, 0.5, 0.25}
+
+// SpeedControl tracks the active speed mode and the multiplier applied to
+// real time by the FramePacer
+type SpeedControl struct {
+	Mode             SpeedMode
+	FastForwardSpeed float64 // Multiplier used while fast-forwarding
+	SlowMotionSpeed  float64 // Multiplier used in slow motion (0.25-1)
+
+	holdFastForward bool // Fast-forward hotkey is currently held down
+}
+
+// NewSpeedControl creates a speed control from a startup multiplier. Values
+// above 1 start in fast-forward, values below 1 start in slow motion.
+func NewSpeedControl(speed float64, fastForwardSpeed float64, uncapped bool) *SpeedControl {
+	s := &SpeedControl{
+		Mode:             SpeedNormal,
+		FastForwardSpeed: clampSpeed(fastForwardSpeed, 1, MaxFastForwardSpeed),
+		SlowMotionSpeed:  1.0,
+	}
+	if fastForwardSpeed <= 0 {
+		s.FastForwardSpeed = DefaultFastForwardSpeed
+	}
+
+	switch {
+	case uncapped:
+		s.Mode = SpeedUncapped
+	case speed > 1:
+		s.Mode = SpeedFastForward
+		s.FastForwardSpeed = clampSpeed(speed, 1, MaxFastForwardSpeed)
+	case speed > 0 && speed < 1:
+		s.Mode = SpeedSlowMotion
+		s.SlowMotionSpeed = clampSpeed(speed, MinSlowMotionSpeed, 1)
+	}
+	return s
+}
+
+func clampSpeed(v float64, lo float64, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// effectiveMode returns the mode that applies this tick, taking the
+// hold-to-fast-forward key into account
+func (s *SpeedControl) effectiveMode() SpeedMode {
+	if s.holdFastForward && s.Mode != SpeedUncapped {
+		return SpeedFastForward
+	}
+	return s.Mode
+}
+
+// Multiplier returns the current emulation speed relative to real hardware.
+// Uncapped mode has no fixed multiplier and returns 0.
+func (s *SpeedControl) Multiplier() float64 {
+	switch s.effectiveMode() {
+	case SpeedFastForward:
+		return s.FastForwardSpeed
+	case SpeedSlowMotion:
+		return s.SlowMotionSpeed
+	case SpeedUncapped:
+		return 0
+	default:
+		return 1.0
+	}
+}
+
+// Uncapped reports whether emulation should run without any frame limiting
+func (s *SpeedControl) Uncapped() bool {
+	return s.effectiveMode() == SpeedUncapped
+}
+
+// SetHoldFastForward updates the state of the hold-to-fast-forward key
+func (s *SpeedControl) SetHoldFastForward(held bool) {
+	s.holdFastForward = held
+}
+
+// ToggleFastForward switches between fast-forward and normal speed
+func (s *SpeedControl) ToggleFastForward() {
+	if s.Mode == SpeedFastForward {
+		s.Mode = SpeedNormal
+	} else {
+		s.Mode = SpeedFastForward
+	}
+}
+
+// CycleSlowMotion steps through 1x, 0.75x, 0.5x and 0.25x
+func (s *SpeedControl) CycleSlowMotion() {
+	next := slowMotionSteps[0]
+	if s.Mode == SpeedSlowMotion {
+		for i, step := range slowMotionSteps {
+			if step == s.SlowMotionSpeed {
+				next = slowMotionSteps[(i+1)%len(slowMotionSteps)]
+				break
+			}
+		}
+	} else {
+		next = slowMotionSteps[1]
+	}
+
+	s.SlowMotionSpeed = next
+	if next == 1.0 {
+		s.Mode = SpeedNormal
+	} else {
+		s.Mode = SpeedSlowMotion
+	}
+}
+
+// ToggleUncapped switches between uncapped and normal speed
+func (s *SpeedControl) ToggleUncapped() {
+	if s.Mode == SpeedUncapped {
+		s.Mode = SpeedNormal
+	} else {
+		s.Mode = SpeedUncapped
+	}
+}
+
+// Label returns a short description for the debug overlay
+func (s *SpeedControl) Label() string {
+	if s.Uncapped() {
+		return "Speed: uncapped"
+	}
+	return fmt.Sprintf("Speed: %.2gx", s.Multiplier())
+}
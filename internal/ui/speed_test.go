@@ -0,0 +1,125 @@
+package ui
+
+import "testing"
+
+func TestSpeedControlStartup(t *testing.T) {
+	tests := []struct {
+		name     string
+		speed    float64
+		ff       float64
+		uncapped bool
+		mode     SpeedMode
+		mult     float64
+	}{
+		{"normal", 1, 4, false, SpeedNormal, 1},
+		{"fast-forward", 3, 4, false, SpeedFastForward, 3},
+		{"fast-forward clamped", 100, 4, false, SpeedFastForward, MaxFastForwardSpeed},
+		{"slow motion", 0.5, 4, false, SpeedSlowMotion, 0.5},
+		{"slow motion clamped", 0.1, 4, false, SpeedSlowMotion, MinSlowMotionSpeed},
+		{"uncapped wins", 3, 4, true, SpeedUncapped, 0},
+		{"default ff speed", 1, 0, false, SpeedNormal, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSpeedControl(tt.speed, tt.ff, tt.uncapped)
+			if s.Mode != tt.mode || s.Multiplier() != tt.mult {
+				t.Errorf("mode %d at %gx, want mode %d at %gx", s.Mode, s.Multiplier(), tt.mode, tt.mult)
+			}
+		})
+	}
+	if s := NewSpeedControl(1, 0, false); s.FastForwardSpeed != DefaultFastForwardSpeed {
+		t.Errorf("fast-forward speed %g, want the default %g", s.FastForwardSpeed, DefaultFastForwardSpeed)
+	}
+}
+
+func TestSpeedControlHoldAndToggle(t *testing.T) {
+	s := NewSpeedControl(1, 4, false)
+
+	// Holding fast-forwards without changing the selected mode
+	s.SetHoldFastForward(true)
+	if s.Multiplier() != 4 || s.Mode != SpeedNormal {
+		t.Errorf("holding: %gx in mode %d, want 4x over normal", s.Multiplier(), s.Mode)
+	}
+	s.SetHoldFastForward(false)
+	if s.Multiplier() != 1 {
+		t.Errorf("released: %gx, want 1x", s.Multiplier())
+	}
+
+	// Hold overrides slow motion and gives it back on release
+	s.CycleSlowMotion()
+	s.SetHoldFastForward(true)
+	if s.Multiplier() != 4 {
+		t.Errorf("holding over slow motion: %gx, want 4x", s.Multiplier())
+	}
+	s.SetHoldFastForward(false)
+	if s.Multiplier() != 0.75 {
+		t.Errorf("released over slow motion: %gx, want 0.75x", s.Multiplier())
+	}
+
+	// Toggle stays on after the hold key is released
+	s.ToggleFastForward()
+	s.SetHoldFastForward(true)
+	s.SetHoldFastForward(false)
+	if s.Mode != SpeedFastForward || s.Multiplier() != 4 {
+		t.Errorf("toggled: mode %d at %gx, want fast-forward at 4x", s.Mode, s.Multiplier())
+	}
+	s.ToggleFastForward()
+	if s.Mode != SpeedNormal {
+		t.Errorf("toggled twice: mode %d, want normal", s.Mode)
+	}
+}
+
+func TestSpeedControlSlowMotionCycle(t *testing.T) {
+	s := NewSpeedControl(1, 4, false)
+	want := []float64{0.75, 0.5, 0.25, 1, 0.75}
+	for i, w := range want {
+		s.CycleSlowMotion()
+		if s.Multiplier() != w {
+			t.Errorf("step %d: %gx, want %gx", i, s.Multiplier(), w)
+		}
+		if (s.Mode == SpeedSlowMotion) != (w < 1) {
+			t.Errorf("step %d: mode %d at %gx", i, s.Mode, w)
+		}
+	}
+
+	// From fast-forward the cycle starts over at 0.75x
+	s.ToggleFastForward()
+	s.CycleSlowMotion()
+	if s.Mode != SpeedSlowMotion || s.Multiplier() != 0.75 {
+		t.Errorf("from fast-forward: mode %d at %gx, want slow motion at 0.75x", s.Mode, s.Multiplier())
+	}
+}
+
+func TestSpeedControlUncapped(t *testing.T) {
+	s := NewSpeedControl(1, 4, false)
+	s.ToggleUncapped()
+	s.SetHoldFastForward(true)
+	if !s.Uncapped() || s.Multiplier() != 0 {
+		t.Errorf("holding fast-forward in uncapped mode: uncapped=%v at %gx, want uncapped", s.Uncapped(), s.Multiplier())
+	}
+	s.ToggleUncapped()
+	if s.Uncapped() || s.Multiplier() != 4 {
+		t.Errorf("leaving uncapped with the hold key down: uncapped=%v at %gx, want 4x", s.Uncapped(), s.Multiplier())
+	}
+}
+
+func TestSpeedControlLabel(t *testing.T) {
+	s := NewSpeedControl(1, 4, false)
+	tests := []struct {
+		apply func()
+		want  string
+	}{
+		{func() {}, "Speed: 1x"},
+		{s.CycleSlowMotion, "Speed: 0.75x"},
+		{s.CycleSlowMotion, "Speed: 0.5x"},
+		{s.CycleSlowMotion, "Speed: 0.25x"},
+		{s.ToggleFastForward, "Speed: 4x"},
+		{s.ToggleUncapped, "Speed: uncapped"},
+	}
+	for _, tt := range tests {
+		tt.apply()
+		if got := s.Label(); got != tt.want {
+			t.Errorf("Label() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package ui
 
 import (
-	"app/internal/cpu"
 	"app/internal/input"
 	"app/internal/logger"
+	"app/internal/movie"
 	"errors"
+	"fmt"
 	"image/color"
+	"strings"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -17,35 +19,65 @@ const (
 	scale        = 4
 )
 
+// UiConfig holds the frontend options chosen on the command line
+type UiConfig struct {
+	ShowFPS          bool    // Show the FPS overlay on startup
+	Speed            float64 // Initial speed multiplier (>1 fast-forward, <1 slow motion)
+	FastForwardSpeed float64 // Multiplier used by the fast-forward hotkeys
+	Uncapped         bool    // Run as fast as possible (benchmarks)
+	Input            InputConfig
+	Movie            *movie.Session // Movie to record or play back, nil for none
+}
+
+// DefaultUiConfig returns the options used when none are given
+func DefaultUiConfig() UiConfig {
+	return UiConfig{
+		Speed:            1.0,
+		FastForwardSpeed: DefaultFastForwardSpeed,
+		Input:            DefaultInputConfig(),
+	}
+}
+
 type Game struct {
 	EmuCtx        *EmuContext
 	VideoImage    *ebiten.Image
-	pixelBuffer   []byte    // Reusable buffer for WritePixels
-	lastFrameTime time.Time // For frame rate limiting
-	showDebugInfo bool      // Toggle FPS display
-	f3Pressed     bool      // Track F3 key state for debouncing
+	pixelBuffer   []byte              // Reusable buffer for WritePixels
+	showDebugInfo bool                // Toggle FPS display
+	prevKeys      map[ebiten.Key]bool // Hotkey state from the previous tick for debouncing
+	bindings      *Bindings
+	speed         *SpeedControl
+	pacer         *FramePacer
+	wasUncapped   bool           // Uncapped state applied to ebiten on the previous tick
+	liveInput     input.State    // Keyboard/gamepad/host input polled this tick
+	movie         *movie.Session // Active movie recording or playback
+	showCheats    bool           // Cheat list overlay (F9)
+
+	// Emulated frames per second, shown in the overlay
+	emuFrames     int
+	emuFPS        float64
+	emuFPSUpdated time.Time
 	// Debug variables
 	debugImage   *ebiten.Image
 	frameCounter int
 }
 
 func NewGame(emuInstance *EmuContext) *Game {
-	LcdInit()
+	emuInstance.PowerOn()
 
-	input.LcdReadFunc = LcdRead
-	input.LcdWriteFunc = LcdWrite
+	bindings, _ := NewBindings(DefaultInputConfig())
 
 	g := &Game{
 		EmuCtx:        emuInstance,
 		VideoImage:    ebiten.NewImage(ScreenWidth, ScreenHeight),
 		pixelBuffer:   make([]byte, ScreenWidth*ScreenHeight*4),
 		showDebugInfo: false, // FPS display off by default
+		prevKeys:      make(map[ebiten.Key]bool),
+		bindings:      bindings,
+		speed:         NewSpeedControl(1.0, DefaultFastForwardSpeed, false),
+		pacer:         NewFramePacer(),
+		emuFPSUpdated: time.Now(),
 	}
 
-	// Run boot ROM simulation immediately
-	bootRomContext := cpu.BootRomCtx()
-	bootRomContext.SimulateBootSequence()
-
 	return g
 }
 
@@ -55,7 +87,33 @@ func (g *Game) Update() error {
 	}
 
 	g.handleInput()
-	g.EmuCtx.StepFrame()
+	g.applySpeedMode()
+
+	if g.speed.Uncapped() {
+		// Run as many frames as fit in the tick budget; only the last one is drawn
+		deadline := time.Now().Add(uncappedTickBudget)
+		for g.EmuCtx.Running && time.Now().Before(deadline) {
+			g.runFrame()
+			g.emuFrames++
+		}
+	} else {
+		// Run whole frames while real time is ahead of emulated time.
+		// Fast-forward runs several frames per tick and skips drawing the
+		// intermediate ones; slow motion runs zero frames on some ticks.
+		g.pacer.Advance(g.speed.Multiplier())
+		for g.pacer.FrameDue() && g.EmuCtx.Running {
+			before := g.EmuCtx.Ticks
+			g.runFrame()
+			g.pacer.Consume(g.EmuCtx.Ticks - before)
+			g.emuFrames++
+		}
+	}
+
+	if elapsed := time.Since(g.emuFPSUpdated); elapsed >= time.Second {
+		g.emuFPS = float64(g.emuFrames) / elapsed.Seconds()
+		g.emuFrames = 0
+		g.emuFPSUpdated = time.Now()
+	}
 
 	if !g.EmuCtx.Running {
 		return ErrEmulationStopped
@@ -64,24 +122,63 @@ func (g *Game) Update() error {
 	return nil
 }
 
-func (g *Game) Draw(screen *ebiten.Image) {
-	// Frame rate limiting: ensure we don't draw more than 60 FPS
-	// This reduces CPU usage when VSync doesn't work
-	targetFrameTime := time.Second / 60
-	elapsed := time.Since(g.lastFrameTime)
-	if elapsed < targetFrameTime {
-		time.Sleep(targetFrameTime - elapsed)
+// runFrame applies the input for the next frame and emulates it. Input is
+// latched once per frame so a movie replays exactly what was recorded.
+func (g *Game) runFrame() {
+	st := g.liveInput
+	if g.movie != nil {
+		st = g.movie.Next(st)
+	}
+	input.SetState(st)
+	g.EmuCtx.StepFrame()
+}
+
+// applySpeedMode toggles VSync when the uncapped mode is entered or left.
+// Ebiten ticks once per displayed frame in both cases; pacing is done by
+// the FramePacer, not by the tick rate.
+func (g *Game) applySpeedMode() {
+	uncapped := g.speed.Uncapped()
+	if uncapped == g.wasUncapped {
+		return
+	}
+	g.wasUncapped = uncapped
+
+	ebiten.SetVsyncEnabled(!uncapped)
+	if !uncapped {
+		// Don't try to catch up on the time spent uncapped
+		g.pacer.Reset()
 	}
-	g.lastFrameTime = time.Now()
+	logger.Info("Uncapped mode: %v", uncapped)
+}
+
+// keyJustPressed reports whether key went down since the previous tick
+func (g *Game) keyJustPressed(key ebiten.Key) bool {
+	pressed := ebiten.IsKeyPressed(key)
+	wasPressed := g.prevKeys[key]
+	g.prevKeys[key] = pressed
+	return pressed && !wasPressed
+}
 
+func (g *Game) Draw(screen *ebiten.Image) {
 	// Normal game drawing
 	screen.Fill(color.RGBA{20, 20, 20, 255})
 
 	g.drawVideoBuffer(screen)
 
-	// Display FPS in top-left corner (if enabled)
+	// Display FPS in top-left corner (if enabled). The movie frame counter
+	// is always shown while a movie is active.
+	var lines []string
 	if g.showDebugInfo {
-		drawDebugInfo(screen)
+		lines = append(lines, fmt.Sprintf("EMU: %.1f %s", g.emuFPS, g.speed.Label()))
+	}
+	if g.movie != nil {
+		lines = append(lines, "Movie: "+g.movie.Status())
+	}
+	if g.showCheats {
+		lines = append(lines, cheatListText(g.EmuCtx.Cheats))
+	}
+	if len(lines) > 0 {
+		drawDebugInfo(screen, strings.Join(lines, "\n"))
 	}
 }
 
@@ -90,40 +187,92 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeigh
 	return ScreenWidth * scale, ScreenHeight * scale
 }
 
-func (g *Game) handleInput() {
-	state := input.GetState()
+// cheatHotkeys toggle the first nine cheats while the cheat list is shown
+var cheatHotkeys = [maxCheatHotkeys]ebiten.Key{
+	ebiten.KeyDigit1, ebiten.KeyDigit2, ebiten.KeyDigit3,
+	ebiten.KeyDigit4, ebiten.KeyDigit5, ebiten.KeyDigit6,
+	ebiten.KeyDigit7, ebiten.KeyDigit8, ebiten.KeyDigit9,
+}
+
+// isHotkey reports whether key is used by handleInput, and so can't be bound
+// to a joypad button
+func isHotkey(key ebiten.Key) bool {
+	switch key {
+	case ebiten.KeySpace, ebiten.KeyF3, ebiten.KeyF4, ebiten.KeyF5, ebiten.KeyF6,
+		ebiten.KeyF7, ebiten.KeyF8, ebiten.KeyF9:
+		return true
+	}
+	for _, k := range cheatHotkeys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
 
+func (g *Game) handleInput() {
 	// Toggle FPS display with F3 key (debounced)
-	f3Current := ebiten.IsKeyPressed(ebiten.KeyF3)
-	if f3Current && !g.f3Pressed {
+	if g.keyJustPressed(ebiten.KeyF3) {
 		g.showDebugInfo = !g.showDebugInfo
 		logger.Info("FPS display: %v", g.showDebugInfo)
 	}
-	g.f3Pressed = f3Current
-
-	// Preserve any input set externally (e.g., via JS postMessage). Only
-	// combine keyboard input with the existing state so host-sent events are
-	// not clobbered each frame.
-	jsB, jsA, jsStart, jsSelect := state.B, state.A, state.Start, state.Select
-	jsUp, jsDown, jsLeft, jsRight := state.Up, state.Down, state.Left, state.Right
-
-	kbB := ebiten.IsKeyPressed(ebiten.KeyZ)
-	kbA := ebiten.IsKeyPressed(ebiten.KeyX)
-	kbStart := ebiten.IsKeyPressed(ebiten.KeyEnter)
-	kbSelect := ebiten.IsKeyPressed(ebiten.KeyTab)
-	kbUp := ebiten.IsKeyPressed(ebiten.KeyArrowUp)
-	kbDown := ebiten.IsKeyPressed(ebiten.KeyArrowDown)
-	kbLeft := ebiten.IsKeyPressed(ebiten.KeyArrowLeft)
-	kbRight := ebiten.IsKeyPressed(ebiten.KeyArrowRight)
-
-	state.B = jsB || kbB
-	state.A = jsA || kbA
-	state.Start = jsStart || kbStart
-	state.Select = jsSelect || kbSelect
-	state.Up = jsUp || kbUp
-	state.Down = jsDown || kbDown
-	state.Left = jsLeft || kbLeft
-	state.Right = jsRight || kbRight
+
+	// Speed hotkeys: hold Space to fast-forward, F4 toggles fast-forward,
+	// F5 cycles slow motion and F6 toggles uncapped mode
+	g.speed.SetHoldFastForward(ebiten.IsKeyPressed(ebiten.KeySpace))
+	if g.keyJustPressed(ebiten.KeyF4) {
+		g.speed.ToggleFastForward()
+		logger.Info("Fast-forward: %s", g.speed.Label())
+	}
+	if g.keyJustPressed(ebiten.KeyF5) {
+		g.speed.CycleSlowMotion()
+		logger.Info("Slow motion: %s", g.speed.Label())
+	}
+	if g.keyJustPressed(ebiten.KeyF6) {
+		g.speed.ToggleUncapped()
+	}
+
+	// F9 shows the cheat list; while it is open 1-9 toggle cheats
+	if g.keyJustPressed(ebiten.KeyF9) {
+		g.showCheats = !g.showCheats
+	}
+	if g.showCheats && g.EmuCtx.Cheats != nil {
+		for i, key := range cheatHotkeys {
+			if !g.keyJustPressed(key) {
+				continue
+			}
+			// The movie header records the cheats, toggling one would desync it
+			if g.movie != nil {
+				logger.Warn("Cheats: can't toggle cheats while a movie is active")
+				continue
+			}
+			g.EmuCtx.Cheats.Toggle(i)
+		}
+	}
+
+	// Movie hotkeys: F7 toggles read-only, F8 records a new branch from the
+	// current frame
+	if g.movie != nil {
+		if g.keyJustPressed(ebiten.KeyF7) {
+			g.movie.ToggleReadOnly()
+			logger.Info("Movie: %s", g.movie.Status())
+		}
+		if g.keyJustPressed(ebiten.KeyF8) {
+			if err := g.movie.Branch(); err != nil {
+				logger.Warn("Movie: can't branch: %v", err)
+			}
+		}
+	}
+
+	// Combine keyboard/gamepad input with the buttons set externally (e.g.,
+	// via JS postMessage). Host state is kept separately so host-sent events
+	// are not clobbered each frame and local releases are not masked.
+	next := g.bindings.Poll()
+	next.Merge(*input.GetHostState())
+	if !g.bindings.AllowOpposing() {
+		next.CancelOpposing()
+	}
+	g.liveInput = next
 }
 
 func (g *Game) drawVideoBuffer(screen *ebiten.Image) {
@@ -223,20 +372,57 @@ func convertColor(value uint32) color.RGBA {
 	}
 }
 
-// UiInit initializes the UI and starts the game loop
-func UiInit(emuInstance *EmuContext, showFPS bool) {
+// saveMovie writes a recorded or branched movie back to disk
+func (g *Game) saveMovie() {
+	if g.movie == nil {
+		return
+	}
+	if err := g.movie.Save(); err != nil {
+		logger.Error("Failed to save movie: %v", err)
+	}
+}
+
+// saveCheats writes toggled or added cheats back to the cheat file
+func (g *Game) saveCheats() {
+	if g.EmuCtx.Cheats == nil {
+		return
+	}
+	if err := g.EmuCtx.Cheats.Save(); err != nil {
+		logger.Error("Failed to save cheats: %v", err)
+	}
+}
+
+// UiInit initializes the UI and runs the game loop until the window closes or
+// emulation stops. The movie and cheats are saved before it returns, also on
+// error.
+func UiInit(emuInstance *EmuContext, cfg UiConfig) error {
 	game := NewGame(emuInstance)
-	game.showDebugInfo = showFPS // Set initial FPS display state
+	game.showDebugInfo = cfg.ShowFPS // Set initial FPS display state
+	game.speed = NewSpeedControl(cfg.Speed, cfg.FastForwardSpeed, cfg.Uncapped)
+	game.pacer = NewFramePacer()
+
+	bindings, err := NewBindings(cfg.Input)
+	if err != nil {
+		logger.Error("Invalid input bindings, using defaults: %v", err)
+		bindings, _ = NewBindings(DefaultInputConfig())
+	}
+	game.bindings = bindings
+	game.movie = cfg.Movie
+	defer game.saveMovie()
+	defer game.saveCheats()
 
 	ebiten.SetWindowSize(ScreenWidth*scale, ScreenHeight*scale)
 	ebiten.SetWindowTitle("Gomulator")
-	ebiten.SetTPS(60)            // Cap at 60 ticks per second (Game Boy native speed)
+	// Tick once per displayed frame; the FramePacer decides how many Game Boy
+	// frames (at ~59.73 Hz) each tick runs, so there is a single limiter
+	ebiten.SetTPS(ebiten.SyncWithFPS)
 	ebiten.SetVsyncEnabled(true) // Enable VSync to cap FPS at monitor refresh rate
 	if err := ebiten.RunGame(game); err != nil {
 		if errors.Is(err, ErrEmulationStopped) {
 			logger.Info("Emulation stopped")
-			return
+			return nil
 		}
-		logger.Fatal("Failed to run game: %v", err)
+		return err
 	}
+	return nil
 }